@@ -1,85 +1,167 @@
 // Pacote principal - ponto de entrada do programa
+// A geração das animações fica no pacote lissajous; aqui ficam as flags, os arquivos
+// de saída e o modo servidor
 package main
 
 import (
-	"image"       // Tipos básicos para trabalhar com imagens
-	"image/color" // Definição de cores
-	"image/gif"   // Codificação/decodificação de arquivos GIF
-	"io"          // Interface para operações de I/O
-	"math"        // Funções matemáticas (Sin, Pi, etc)
-	"math/rand"   // Geração de números aleatórios
-	"os"          // Interação com sistema operacional (criar arquivos)
+	"errors"                 // Criação de erros de validação
+	"flag"                   // Leitura dos parâmetros da linha de comando
+	"fmt"                    // Formatação das mensagens de erro
+	"gif_animados/lissajous" // Geração das animações
+	"log"                    // Registro de erros do modo servidor
+	"math/rand"              // Geração de números aleatórios
+	"net/http"               // Servidor HTTP (flag -http)
+	"os"                     // Interação com sistema operacional (criar arquivos)
+	"time"                   // Semente padrão baseada no relógio
 )
 
-// Paleta de cores: [0]=preto (fundo), [1]=verde, [2]=laranja
-var palette = []color.Color{color.Black, color.RGBA{0x00, 0xff, 0x00, 0xff}, color.RGBA{0xff, 0x7f, 0x00, 0xff}}
+// config acrescenta às opções da animação o que só existe na linha de comando
+type config struct {
+	lissajous.Options
+	seed   int64  // Semente usada para sortear freq quando ela não é informada
+	output string // Caminho do arquivo de saída ("-" = saída padrão)
+}
 
-// Constantes que representam os índices das cores na paleta
-const (
-	blackIndex  = 0 // Índice 0: preto (cor de fundo)
-	greenIndex  = 1 // Índice 1: verde
-	orangeIndex = 2 // Índice 2: laranja
-)
+// defaultConfig devolve os valores originais do livro
+// A frequência fica zerada: main a sorteia a partir de seed quando -freq não é informada
+func defaultConfig() config {
+	return config{Options: lissajous.DefaultOptions(), output: "lissajous.gif"}
+}
 
-func main() {
-	// Cria um novo arquivo chamado "lissajous.gif" para salvar a animação
-	f, err := os.Create("lissajous.gif")
-	if err != nil {
-		// Se houver erro ao criar o arquivo, para o programa
-		panic(err)
+// validate confere as opções da animação e o caminho de saída
+func (c config) validate() error {
+	// O formato deduzido de -o entra nas opções: Validate rejeita, por exemplo, -sweep com SVG
+	opts := c.Options
+	opts.Format = lissajous.ResolveFormat(c.Format, c.output)
+	if err := opts.Validate(); err != nil {
+		return err
 	}
-	// defer: garante que o arquivo será fechado ao final da função main
-	defer f.Close()
-	// Chama a função lissajous passando o arquivo como destino
-	lissajous(f)
+	switch {
+	case c.output == "":
+		return errors.New("o caminho de saída (-o) não pode ser vazio")
+	case c.output == "-" && opts.Format == lissajous.FormatPNGSeq:
+		return errors.New("o formato pngseq precisa de um padrão de arquivo em -o (ex.: frame_%03d.png)")
+	}
+	return nil
 }
 
-// Função que gera a animação de figuras de Lissajous
-func lissajous(out io.Writer) {
-	// Constantes que controlam a animação
-	const (
-		cycles  = 5     // Número de oscilações completas do oscilador x
-		res     = 0.001 // Resolução angular (menor = mais suave)
-		size    = 100   // Tamanho da imagem (canvas será 2*size+1 x 2*size+1)
-		nframes = 64    // Número de frames na animação
-		delay   = 8     // Delay entre frames em unidades de 10ms (80ms)
-	)
-	// Frequência relativa do oscilador y (aleatória entre 0 e 3)
-	freq := rand.Float64() * 3.0
-	// Estrutura GIF: LoopCount=0 significa loop infinito
-	anim := gif.GIF{LoopCount: 0} //no livro encontra-se GIF{LoopCount: nframes} foi modificado para GIF{LoopCount: 0}
-	// Diferença de fase entre os osciladores x e y
-	phase := 0.0
-	// Loop que cria cada frame da animação
-	for i := 0; i < nframes; i++ {
-		// Define o retângulo da imagem: de (0,0) até (2*size+1, 2*size+1) = 201x201 pixels
-		rect := image.Rect(0, 0, 2*size+1, 2*size+1)
-		// Cria uma imagem paletizada (usa índices da paleta ao invés de RGB direto)
-		img := image.NewPaletted(rect, palette)
-		// Loop que desenha a curva de Lissajous para este frame
-		for t := 0.0; t < cycles*2*math.Pi; t += res {
-			// Calcula coordenada x usando seno de t (oscilação simples)
-			x := math.Sin(t)
-			// Calcula coordenada y usando seno de t*freq+phase (oscilação com frequência diferente e fase)
-			y := math.Sin(t*freq + phase)
-			// Alterna entre verde e laranja a cada frame
-			colorIndex := uint8(greenIndex) // Por padrão usa verde
-			if i%2 == 0 {                   // Se o número do frame for par
-				colorIndex = uint8(orangeIndex) // Usa laranja
-			}
-			// Define o pixel na posição calculada com a cor escolhida
-			// size+int(x*size+0.5): converte coordenada [-1,1] para [0, 2*size]
-			// +0.5 faz arredondamento correto ao converter float para int
-			img.SetColorIndex(size+int(x*size+0.5), size+int(y*size+0.5),
-				colorIndex)
+func main() {
+	// Parte dos valores do livro e deixa cada um ser sobrescrito por uma flag
+	cfg := defaultConfig()
+	flag.Float64Var(&cfg.Cycles, "cycles", cfg.Cycles, "número de oscilações completas do oscilador x")
+	flag.Float64Var(&cfg.Res, "res", cfg.Res, "resolução angular (menor = mais suave)")
+	flag.IntVar(&cfg.Size, "size", cfg.Size, "tamanho da imagem (canvas de 2*size+1 pixels)")
+	flag.IntVar(&cfg.NFrames, "nframes", cfg.NFrames, "número de frames da animação")
+	flag.IntVar(&cfg.Delay, "delay", cfg.Delay, "delay entre frames em unidades de 10ms")
+	flag.Float64Var(&cfg.Freq, "freq", cfg.Freq, "frequência relativa do oscilador y (padrão: sorteada entre 0 e 3)")
+	flag.Int64Var(&cfg.seed, "seed", cfg.seed, "semente do sorteio de freq; a mesma semente gera sempre o mesmo GIF (padrão: relógio)")
+	flag.Float64Var(&cfg.PhaseStep, "phase", cfg.PhaseStep, "incremento de fase entre frames")
+	flag.Func("palette", "nome da paleta ("+lissajous.PaletteNames()+`) ou cores em hexadecimal separadas por vírgula, a primeira é o fundo (padrão "classico")`, func(s string) error {
+		p, err := lissajous.ParsePalette(s)
+		cfg.Palette = p
+		return err
+	})
+	flag.Var(&cfg.ColorMode, "colormode", "coloração da curva: alternate (uma cor por frame), t, frame ou radius (gradientes)")
+	flag.Var(&cfg.Render, "render", "desenho da curva: points (pixels isolados, como no livro) ou lines (segmentos com anti-aliasing)")
+	flag.Float64Var(&cfg.Stroke, "stroke", cfg.Stroke, "largura do traço em pixels no modo lines")
+	flag.StringVar(&cfg.Curve, "curve", cfg.Curve, "tipo de curva: "+lissajous.CurveNames())
+	flag.Var(&cfg.XOsc, "xosc", `osciladores do eixo x do harmonógrafo, "freq:amp[:fase[:decay]],..."`)
+	flag.Var(&cfg.YOsc, "yosc", `osciladores do eixo y do harmonógrafo, "freq:amp[:fase[:decay]],..."`)
+	flag.Var(&cfg.Rotary, "rotary", `pêndulos rotatórios do harmonógrafo, "freq:amp[:fase[:decay]],..."`)
+	flag.Float64Var(&cfg.Decay, "decay", cfg.Decay, "decaimento exponencial de amplitude aplicado a qualquer curva")
+	flag.Float64Var(&cfg.ZFreq, "zfreq", cfg.ZFreq, "frequência relativa do eixo z (curva lissajous3d)")
+	flag.Float64Var(&cfg.Tilt, "tilt", cfg.Tilt, "inclinação da câmera em radianos (curva lissajous3d)")
+	flag.Var(&cfg.Format, "format", "formato de saída: gif, apng, pngseq ou svg (padrão: deduzido da extensão de -o)")
+	flag.StringVar(&cfg.output, "o", cfg.output, `arquivo de saída ("-" para a saída padrão; com %d, ex. frame_%03d.png, grava uma sequência de PNGs)`)
+	flag.IntVar(&cfg.Trail, "trail", cfg.Trail, "rastro de fósforo: quantos frames anteriores continuam visíveis, apagando aos poucos")
+	flag.BoolVar(&cfg.Optimize, "optimize", cfg.Optimize, "GIF menor: grava só o retângulo de cada frame que mudou em relação ao anterior")
+	flag.IntVar(&cfg.Workers, "workers", cfg.Workers, "frames desenhados em paralelo (0 = um por CPU)")
+	flag.Var(&cfg.Sweep, "sweep", `folha de contato: razões de frequência x:y, uma por linha ("1:2,2:3,1.5"), ou N para todas as razões até N:N`)
+	flag.Func("offsets", "defasagens das colunas de -sweep, em múltiplos de Pi (padrão \"0,0.125,0.25,0.375,0.5\")", func(s string) error {
+		o, err := lissajous.ParseOffsets(s)
+		cfg.Offsets = o
+		return err
+	})
+	wavPath := flag.String("wav", "", "arquivo WAV estéreo de 16 bits: desenha esquerdo x direito como um osciloscópio XY")
+	httpAddr := flag.String("http", "", `em vez de gravar um arquivo, serve /lissajous neste endereço (ex.: "localhost:8000")`)
+	flag.Parse()
+
+	// Argumentos soltos normalmente são engano (ex.: "-size 100 200")
+	if flag.NArg() > 0 {
+		fail(fmt.Errorf("argumento inesperado: %q", flag.Arg(0)))
+	}
+
+	// Modo servidor: cada requisição traz seus próprios parâmetros na query string
+	if *httpAddr != "" {
+		http.HandleFunc("/lissajous", lissajousHandler)
+		log.Printf("servindo em http://%s/lissajous", *httpAddr)
+		log.Fatal(http.ListenAndServe(*httpAddr, nil))
+	}
+
+	// Modo osciloscópio: os canais do WAV substituem os osciladores
+	if *wavPath != "" {
+		sig, err := lissajous.ReadWAVFile(*wavPath)
+		if err != nil {
+			fail(err)
+		}
+		// Sem -nframes, a animação cobre o áudio inteiro
+		if !isFlagSet("nframes") {
+			cfg.NFrames = 0
 		}
-		// Incrementa a fase para o próximo frame (faz a figura girar)
-		phase += 0.1
-		// Adiciona o delay deste frame à animação
-		anim.Delay = append(anim.Delay, delay)
-		// Adiciona a imagem deste frame à animação
-		anim.Image = append(anim.Image, img)
+		if err := cfg.ApplyAudio(sig); err != nil {
+			fail(err)
+		}
+		fmt.Fprintf(os.Stderr, "gif_animados: %s: %.2f s a %d Hz, %d frames\n", *wavPath, sig.Duration(), sig.Rate, cfg.NFrames)
+	}
+
+	// A folha de contato é uma imagem estática, a menos que -nframes peça a animação
+	if len(cfg.Sweep) > 0 && !isFlagSet("nframes") {
+		cfg.NFrames = 1
 	}
-	// Codifica toda a animação GIF e escreve no destino (arquivo)
-	gif.EncodeAll(out, &anim)
+
+	// Sem -seed, usa o relógio - mas a semente é registrada abaixo para poder repetir a animação
+	if !isFlagSet("seed") {
+		cfg.seed = time.Now().UnixNano()
+	}
+	// Sem -freq, a frequência relativa do oscilador y é aleatória entre 0 e 3, como no livro
+	if !isFlagSet("freq") {
+		cfg.Freq = randomFreq(cfg.seed)
+	}
+	// Registra no stderr os valores usados (o stdout pode estar recebendo o GIF)
+	// Com -wav e -sweep a frequência sorteada não é usada
+	if cfg.Audio == nil && len(cfg.Sweep) == 0 {
+		fmt.Fprintf(os.Stderr, "gif_animados: seed=%d freq=%g\n", cfg.seed, cfg.Freq)
+	}
+
+	if err := cfg.validate(); err != nil {
+		fail(err)
+	}
+
+	// Grava no formato escolhido em -format (ou deduzido da extensão de -o)
+	if err := writeOutput(cfg); err != nil {
+		fail(err)
+	}
+}
+
+// randomFreq sorteia a frequência relativa do oscilador y (entre 0 e 3) a partir de seed
+// Usa um gerador próprio: a mesma semente sempre devolve a mesma frequência
+func randomFreq(seed int64) float64 {
+	return rand.New(rand.NewSource(seed)).Float64() * 3.0
+}
+
+// isFlagSet informa se a flag name foi passada explicitamente na linha de comando
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// fail imprime o erro no stderr e encerra o programa com código 1
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "gif_animados: %v\n", err)
+	os.Exit(1)
 }
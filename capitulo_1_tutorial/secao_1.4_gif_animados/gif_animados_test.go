@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"gif_animados/lissajous"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// -update regrava os arquivos de testdata com a saída atual:
+//
+//	go test -run TestLissajousGolden -update
+var update = flag.Bool("update", false, "regrava os arquivos golden em testdata/")
+
+// TestLissajousGolden garante que a mesma configuração gera sempre um GIF idêntico byte a byte
+func TestLissajousGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  func() config
+	}{
+		{"seed1", func() config {
+			cfg := smallConfig()
+			cfg.seed = 1
+			cfg.Freq = randomFreq(cfg.seed)
+			return cfg
+		}},
+		{"seed42", func() config {
+			cfg := smallConfig()
+			cfg.seed = 42
+			cfg.Freq = randomFreq(cfg.seed)
+			return cfg
+		}},
+		{"freq1.5", func() config {
+			cfg := smallConfig()
+			cfg.Freq = 1.5
+			cfg.PhaseStep = 0.3
+			return cfg
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg()
+			if err := cfg.Validate(); err != nil {
+				t.Fatalf("configuração inválida: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := lissajous.Render(&buf, cfg.Options); err != nil {
+				t.Fatalf("lissajous: %v", err)
+			}
+
+			golden := filepath.Join("testdata", tt.name+".gif")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (rode com -update para criar)", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("GIF difere de %s: %d bytes gerados, %d esperados", golden, buf.Len(), len(want))
+			}
+		})
+	}
+}
+
+// TestRandomFreq confere que a semente determina a frequência e que ela fica entre 0 e 3
+func TestRandomFreq(t *testing.T) {
+	for _, seed := range []int64{0, 1, 42, -7} {
+		f := randomFreq(seed)
+		if f < 0 || f >= 3 {
+			t.Errorf("randomFreq(%d) = %g, fora de [0, 3)", seed, f)
+		}
+		if g := randomFreq(seed); g != f {
+			t.Errorf("randomFreq(%d) não é determinística: %g != %g", seed, f, g)
+		}
+	}
+}
+
+// smallConfig reduz a animação padrão para manter os arquivos golden pequenos
+func smallConfig() config {
+	cfg := defaultConfig()
+	cfg.Size = 40
+	cfg.NFrames = 6
+	return cfg
+}
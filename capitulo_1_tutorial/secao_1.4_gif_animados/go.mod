@@ -0,0 +1,3 @@
+module gif_animados
+
+go 1.21
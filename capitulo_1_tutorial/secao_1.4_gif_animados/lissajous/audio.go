@@ -0,0 +1,155 @@
+// Modo osciloscópio: em vez de dois senos sintéticos, os osciladores x e y são os
+// canais esquerdo e direito de um arquivo WAV, como um osciloscópio no modo XY
+package lissajous
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// AudioSignal são as amostras de um WAV estéreo, normalizadas para [-1, 1)
+type AudioSignal struct {
+	Rate        int       // Amostras por segundo (por canal)
+	Left, Right []float64 // Canal esquerdo (eixo x) e direito (eixo y)
+}
+
+// Duration é a duração do áudio em segundos
+func (a *AudioSignal) Duration() float64 {
+	return float64(len(a.Left)) / float64(a.Rate)
+}
+
+// Formatos aceitos no campo AudioFormat do bloco fmt
+const (
+	wavFormatPCM        = 1      // PCM inteiro
+	wavFormatExtensible = 0xfffe // WAVE_FORMAT_EXTENSIBLE: o formato real vem no SubFormat
+)
+
+// ReadWAVFile lê o arquivo WAV em path (ver ReadWAV)
+func ReadWAVFile(path string) (*AudioSignal, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWAV(f)
+}
+
+// ReadWAV decodifica um WAV PCM de 16 bits com 2 canais
+// O arquivo é uma sequência de blocos RIFF (id de 4 letras, tamanho, dados); só "fmt "
+// e "data" interessam, os demais (LIST, fact...) são pulados
+func ReadWAV(r io.Reader) (*AudioSignal, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, errors.New("wav: o arquivo não começa com RIFF/WAVE")
+	}
+	b = b[12:]
+
+	var fmtChunk, data []byte
+	for len(b) >= 8 {
+		id, n := string(b[0:4]), binary.LittleEndian.Uint32(b[4:8])
+		b = b[8:]
+		if uint64(n) > uint64(len(b)) {
+			// Gravadores interrompidos deixam o tamanho do data maior que o arquivo:
+			// aproveita o que existe
+			if id != "data" {
+				return nil, fmt.Errorf("wav: bloco %q truncado", id)
+			}
+			n = uint32(len(b))
+		}
+		switch id {
+		case "fmt ":
+			fmtChunk = b[:n]
+		case "data":
+			data = b[:n]
+		}
+		// Blocos de tamanho ímpar têm um byte de preenchimento
+		b = b[min(uint64(len(b)), uint64(n)+uint64(n&1)):]
+	}
+	if fmtChunk == nil || data == nil {
+		return nil, errors.New("wav: blocos fmt e data são obrigatórios")
+	}
+	if len(fmtChunk) < 16 {
+		return nil, errors.New("wav: bloco fmt curto demais")
+	}
+
+	format := binary.LittleEndian.Uint16(fmtChunk[0:2])
+	channels := binary.LittleEndian.Uint16(fmtChunk[2:4])
+	rate := binary.LittleEndian.Uint32(fmtChunk[4:8])
+	bits := binary.LittleEndian.Uint16(fmtChunk[14:16])
+	if format == wavFormatExtensible && len(fmtChunk) >= 26 {
+		// Os 2 primeiros bytes do GUID SubFormat repetem o código do formato
+		format = binary.LittleEndian.Uint16(fmtChunk[24:26])
+	}
+	switch {
+	case format != wavFormatPCM:
+		return nil, fmt.Errorf("wav: formato %#x não suportado (só PCM)", format)
+	case channels != 2:
+		return nil, fmt.Errorf("wav: %d canais, o modo XY precisa de áudio estéreo (2 canais)", channels)
+	case bits != 16:
+		return nil, fmt.Errorf("wav: amostras de %d bits não suportadas (só 16 bits)", bits)
+	case rate == 0:
+		return nil, errors.New("wav: taxa de amostragem zero")
+	}
+
+	// Cada quadro tem 4 bytes: esquerdo e direito, int16 little-endian
+	frames := len(data) / 4
+	if frames == 0 {
+		return nil, errors.New("wav: o arquivo não tem amostras")
+	}
+	sig := &AudioSignal{Rate: int(rate), Left: make([]float64, frames), Right: make([]float64, frames)}
+	for i := range sig.Left {
+		sig.Left[i] = float64(int16(binary.LittleEndian.Uint16(data[4*i:]))) / 32768
+		sig.Right[i] = float64(int16(binary.LittleEndian.Uint16(data[4*i+2:]))) / 32768
+	}
+	return sig, nil
+}
+
+// ApplyAudio ajusta as opções para desenhar sig em vez de uma curva sintética
+// Cada frame mostra a janela de áudio que toca durante ele (delay*10ms), então o GIF
+// anda na mesma velocidade do som; a fase passa a ser o início da janela em segundos
+// e t percorre uma amostra por passo. Com NFrames = 0, a animação cobre o áudio inteiro;
+// com NFrames > 0, só o começo do áudio é desenhado
+func (c *Options) ApplyAudio(sig *AudioSignal) error {
+	if c.Delay <= 0 {
+		return errors.New("o modo -wav precisa de delay maior que zero (é a duração de cada frame)")
+	}
+	window := float64(c.Delay) / 100
+	if c.NFrames == 0 {
+		c.NFrames = int(math.Ceil(sig.Duration() / window))
+		if c.NFrames > maxFrames {
+			return fmt.Errorf("%.1f s de áudio em frames de %d ms dão %d frames (máximo %d): aumente -delay ou use -nframes",
+				sig.Duration(), c.Delay*10, c.NFrames, maxFrames)
+		}
+	}
+	c.Audio = sig
+	c.Cycles = 1
+	c.PhaseStep = window
+	c.Res = 2 * math.Pi / (window * float64(sig.Rate))
+	return nil
+}
+
+// audioCurve é o osciloscópio XY: x = canal esquerdo, y = canal direito
+type audioCurve struct {
+	sig    *AudioSignal
+	window float64 // Duração da janela de cada frame em segundos
+}
+
+func newAudioCurve(cfg Options) curve {
+	return audioCurve{sig: cfg.Audio, window: cfg.PhaseStep}
+}
+
+// point devolve a amostra tocada em phase + t/(2*Pi)*window segundos
+// Depois do fim do áudio, o feixe fica parado na última amostra
+func (c audioCurve) point(t, phase float64) (x, y float64) {
+	sec := phase + t/(2*math.Pi)*c.window
+	i := min(max(int(sec*float64(c.sig.Rate)), 0), len(c.sig.Left)-1)
+	// O y da imagem cresce para baixo: o sinal é invertido para o positivo ficar em cima
+	return c.sig.Left[i], -c.sig.Right[i]
+}
@@ -0,0 +1,145 @@
+package lissajous
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+// wavBytes monta um WAV em memória com os blocos fmt (PCM), um bloco extra e data
+func wavBytes(channels, bits uint16, rate uint32, samples []int16) []byte {
+	var fmtChunk, data, out bytes.Buffer
+	blockAlign := channels * bits / 8
+	for _, v := range []any{uint16(wavFormatPCM), channels, rate, rate * uint32(blockAlign), blockAlign, bits} {
+		binary.Write(&fmtChunk, binary.LittleEndian, v)
+	}
+	binary.Write(&data, binary.LittleEndian, samples)
+	chunk := func(id string, b []byte) {
+		out.WriteString(id)
+		binary.Write(&out, binary.LittleEndian, uint32(len(b)))
+		out.Write(b)
+		if len(b)%2 == 1 {
+			out.WriteByte(0)
+		}
+	}
+	out.WriteString("RIFF\x00\x00\x00\x00WAVE")
+	chunk("fmt ", fmtChunk.Bytes())
+	chunk("LIST", []byte("odd")) // Tamanho ímpar: testa o byte de preenchimento
+	chunk("data", data.Bytes())
+	return out.Bytes()
+}
+
+// circleWAV gera n quadros de um círculo: esquerdo = cos, direito = sin, uma volta a cada period quadros
+func circleWAV(n, period int, rate uint32) []byte {
+	samples := make([]int16, 0, 2*n)
+	for i := 0; i < n; i++ {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(period))
+		samples = append(samples, int16(cos*16384), int16(sin*16384))
+	}
+	return wavBytes(2, 16, rate, samples)
+}
+
+// TestReadWAV confere a taxa, a quantidade de quadros e a normalização dos canais
+func TestReadWAV(t *testing.T) {
+	sig, err := ReadWAV(bytes.NewReader(wavBytes(2, 16, 8000, []int16{0, 16384, -32768, 32767, 100, -100})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.Rate != 8000 {
+		t.Errorf("rate = %d, esperado 8000", sig.Rate)
+	}
+	wantL := []float64{0, -1, 100.0 / 32768}
+	wantR := []float64{0.5, 32767.0 / 32768, -100.0 / 32768}
+	if len(sig.Left) != 3 || len(sig.Right) != 3 {
+		t.Fatalf("%d/%d amostras, esperadas 3", len(sig.Left), len(sig.Right))
+	}
+	for i := range wantL {
+		if sig.Left[i] != wantL[i] || sig.Right[i] != wantR[i] {
+			t.Errorf("amostra %d = (%g, %g), esperado (%g, %g)", i, sig.Left[i], sig.Right[i], wantL[i], wantR[i])
+		}
+	}
+}
+
+// TestReadWAVRejects confere as mensagens para arquivos fora do formato aceito
+func TestReadWAVRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"não RIFF", []byte("ID3 qualquer coisa"), "RIFF/WAVE"},
+		{"mono", wavBytes(1, 16, 8000, []int16{1, 2}), "estéreo"},
+		{"8 bits", wavBytes(2, 8, 8000, []int16{1, 2}), "8 bits"},
+		{"vazio", wavBytes(2, 16, 8000, nil), "não tem amostras"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadWAV(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro = %v, esperado algo com %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestAudioAnimation desenha um círculo vindo do WAV e confere frames e pixels:
+// todo pixel aceso precisa estar a meio raio do centro (amplitude 16384/32768), com a
+// folga do arredondamento do livro (int trunca em direção ao zero nos negativos)
+func TestAudioAnimation(t *testing.T) {
+	sig, err := ReadWAV(bytes.NewReader(circleWAV(1000, 50, 10000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := smallConfig()
+	cfg.Delay = 2 // Frames de 20 ms: 0,1 s de áudio viram 5 frames
+	cfg.NFrames = 0
+	if err := cfg.ApplyAudio(sig); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("configuração inválida: %v", err)
+	}
+	if cfg.NFrames != 5 {
+		t.Errorf("nframes = %d, esperado 5", cfg.NFrames)
+	}
+	anim, err := Animate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	center, radius := float64(cfg.Size), float64(cfg.Size)/2
+	for f, img := range anim.Image {
+		lit := 0
+		for y := 0; y < img.Rect.Dy(); y++ {
+			for x := 0; x < img.Rect.Dx(); x++ {
+				if img.ColorIndexAt(x, y) == blackIndex {
+					continue
+				}
+				lit++
+				if d := math.Hypot(float64(x)-center, float64(y)-center); math.Abs(d-radius) > 2 {
+					t.Fatalf("frame %d: pixel (%d, %d) a %.1f do centro, esperado %.1f", f, x, y, d, radius)
+				}
+			}
+		}
+		if lit < 50 {
+			t.Errorf("frame %d: só %d pixels acesos", f, lit)
+		}
+	}
+}
+
+// TestApplyAudioTooLong confere que áudio longo demais para os frames é um erro claro
+func TestApplyAudioTooLong(t *testing.T) {
+	sig := &AudioSignal{Rate: 1000, Left: make([]float64, 20000), Right: make([]float64, 20000)}
+	cfg := DefaultOptions()
+	cfg.Delay = 1 // 20 s em frames de 10 ms = 2000 frames
+	cfg.NFrames = 0
+	if err := cfg.ApplyAudio(sig); err == nil || !strings.Contains(err.Error(), "-delay") {
+		t.Errorf("erro = %v, esperado sugestão de -delay", err)
+	}
+	// Com NFrames informado, só o começo do áudio é desenhado
+	cfg.NFrames = 10
+	if err := cfg.ApplyAudio(sig); err != nil || cfg.NFrames != 10 {
+		t.Errorf("com nframes 10: %v, %d frames", err, cfg.NFrames)
+	}
+}
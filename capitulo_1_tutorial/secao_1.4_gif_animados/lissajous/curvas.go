@@ -0,0 +1,246 @@
+// Motor de curvas: a figura de Lissajous do livro é um caso particular de uma
+// família maior (harmonógrafos, movimento rotatório e Lissajous em 3D)
+package lissajous
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// curve calcula um ponto da figura para o parâmetro t e a fase do frame atual
+// Os pontos devolvidos ficam sempre em [-1, 1] nos dois eixos
+type curve interface {
+	point(t, phase float64) (x, y float64)
+}
+
+// curvePresets associa cada nome aceito em -curve à função que monta a curva
+var curvePresets = map[string]func(cfg Options) curve{
+	"lissajous":    newLissajousCurve,
+	"harmonograph": newHarmonograph,
+	"rotary":       newRotaryHarmonograph,
+	"lissajous3d":  newLissajous3D,
+}
+
+// CurveNames lista os presets em ordem alfabética (para mensagens de ajuda)
+func CurveNames() string {
+	names := make([]string, 0, len(curvePresets))
+	for name := range curvePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// newCurve monta a curva escolhida em Curve, aplicando o decaimento global Decay
+func newCurve(cfg Options) (curve, error) {
+	preset, ok := curvePresets[cfg.Curve]
+	if !ok {
+		return nil, fmt.Errorf("curva %q desconhecida (use %s)", cfg.Curve, CurveNames())
+	}
+	c := preset(cfg)
+	// Com -wav, a curva vem do áudio (ver audio.go)
+	if cfg.Audio != nil {
+		c = newAudioCurve(cfg)
+	}
+	if cfg.Decay != 0 {
+		c = decayed{c, cfg.Decay}
+	}
+	return c, nil
+}
+
+// lissajousCurve é a curva original do livro: x=sin(t), y=sin(t*freq+phase)
+type lissajousCurve struct {
+	freq float64 // Frequência relativa do oscilador y
+}
+
+func newLissajousCurve(cfg Options) curve {
+	return lissajousCurve{cfg.Freq}
+}
+
+func (c lissajousCurve) point(t, phase float64) (x, y float64) {
+	// Calcula coordenada x usando seno de t (oscilação simples)
+	x = math.Sin(t)
+	// Calcula coordenada y usando seno de t*freq+phase (oscilação com frequência diferente e fase)
+	y = math.Sin(t*c.freq + phase)
+	return x, y
+}
+
+// Oscillator é um pêndulo amortecido: amp * sin(freq*t + phase) * e^(-decay*t)
+type Oscillator struct {
+	Freq, Amp, Phase, Decay float64
+}
+
+func (o Oscillator) at(t, phase float64) float64 {
+	return o.Amp * math.Sin(o.Freq*t+o.Phase+phase) * math.Exp(-o.Decay*t)
+}
+
+// maxOscillators é o máximo de osciladores em cada lista (-xosc, -yosc, -rotary): cada um
+// é um seno e uma exponencial a mais em todas as amostras de todos os frames
+const maxOscillators = 8
+
+// ParseOscillators interpreta uma lista "freq:amp[:fase[:decay]]" separada por vírgulas
+// Ex.: "2:1,3.01:0.5:1.57:0.01" são dois osciladores somados no mesmo eixo
+func ParseOscillators(s string) ([]Oscillator, error) {
+	specs := strings.Split(s, ",")
+	if len(specs) > maxOscillators {
+		return nil, fmt.Errorf("%d osciladores, o máximo é %d por eixo", len(specs), maxOscillators)
+	}
+	var oscs []Oscillator
+	for _, spec := range specs {
+		fields := strings.Split(strings.TrimSpace(spec), ":")
+		if len(fields) < 2 || len(fields) > 4 {
+			return nil, fmt.Errorf("oscilador %q inválido: use freq:amp[:fase[:decay]]", spec)
+		}
+		var v [4]float64
+		for i, f := range fields {
+			x, err := strconv.ParseFloat(f, 64)
+			if err != nil || !finite(x) {
+				return nil, fmt.Errorf("oscilador %q inválido: %q não é um número finito", spec, f)
+			}
+			v[i] = x
+		}
+		if v[3] < 0 {
+			return nil, fmt.Errorf("oscilador %q inválido: o decay não pode ser negativo", spec)
+		}
+		oscs = append(oscs, Oscillator{Freq: v[0], Amp: v[1], Phase: v[2], Decay: v[3]})
+	}
+	return oscs, nil
+}
+
+// OscillatorList permite usar uma lista de osciladores direto em flag.Var
+type OscillatorList []Oscillator
+
+func (l *OscillatorList) String() string {
+	if l == nil {
+		return ""
+	}
+	specs := make([]string, len(*l))
+	for i, o := range *l {
+		specs[i] = fmt.Sprintf("%g:%g:%g:%g", o.Freq, o.Amp, o.Phase, o.Decay)
+	}
+	return strings.Join(specs, ",")
+}
+
+func (l *OscillatorList) Set(s string) error {
+	oscs, err := ParseOscillators(s)
+	*l = oscs
+	return err
+}
+
+// harmonograph soma vários pêndulos amortecidos em cada eixo
+// rotary são pêndulos em movimento circular, que somam seno em x e cosseno em y
+// A fase do frame é somada aos osciladores de y, como na curva do livro
+type harmonograph struct {
+	x, y, rotary []Oscillator
+	scale        float64 // 1 / maior amplitude possível, para caber em [-1, 1]
+}
+
+// newHarmonograph usa os osciladores de cfg ou, se ausentes, um harmonógrafo clássico
+// de dois pêndulos por eixo, com decaimento que reduz a amplitude a 10% no fim da curva
+func newHarmonograph(cfg Options) curve {
+	d := presetDecay(cfg)
+	x := []Oscillator{{1, 1, 0, d}, {3.01, 0.5, math.Pi / 2, 1.5 * d}}
+	y := []Oscillator{{cfg.Freq, 1, 0, d}, {2.99, 0.5, 0, 1.5 * d}}
+	return buildHarmonograph(cfg, x, y, nil)
+}
+
+// newRotaryHarmonograph acrescenta um pêndulo rotatório ao harmonógrafo clássico
+func newRotaryHarmonograph(cfg Options) curve {
+	d := presetDecay(cfg)
+	x := []Oscillator{{1, 1, 0, d}}
+	y := []Oscillator{{cfg.Freq, 1, 0, d}}
+	rotary := []Oscillator{{2.005, 0.7, 0, 2 * d}}
+	return buildHarmonograph(cfg, x, y, rotary)
+}
+
+// presetDecay escolhe o decaimento dos presets: amplitude cai a 10% no fim da curva
+func presetDecay(cfg Options) float64 {
+	return math.Ln10 / (cfg.Cycles * 2 * math.Pi)
+}
+
+// buildHarmonograph substitui os osciladores padrão pelos informados em -xosc, -yosc e -rotary
+func buildHarmonograph(cfg Options, x, y, rotary []Oscillator) curve {
+	if len(cfg.XOsc) > 0 {
+		x = cfg.XOsc
+	}
+	if len(cfg.YOsc) > 0 {
+		y = cfg.YOsc
+	}
+	if len(cfg.Rotary) > 0 {
+		rotary = cfg.Rotary
+	}
+	h := harmonograph{x: x, y: y, rotary: rotary}
+	// No pior caso todos os senos valem 1 ao mesmo tempo: a soma das amplitudes
+	var ax, ay float64
+	for _, o := range x {
+		ax += math.Abs(o.Amp)
+	}
+	for _, o := range y {
+		ay += math.Abs(o.Amp)
+	}
+	for _, o := range rotary {
+		ax += math.Abs(o.Amp)
+		ay += math.Abs(o.Amp)
+	}
+	if m := math.Max(ax, ay); m > 0 {
+		h.scale = 1 / m
+	}
+	return h
+}
+
+func (h harmonograph) point(t, phase float64) (x, y float64) {
+	for _, o := range h.x {
+		x += o.at(t, 0)
+	}
+	for _, o := range h.y {
+		y += o.at(t, phase)
+	}
+	for _, o := range h.rotary {
+		// O cosseno é o seno adiantado de Pi/2: o pêndulo descreve um círculo
+		x += o.at(t, 0)
+		y += o.at(t, math.Pi/2)
+	}
+	return x * h.scale, y * h.scale
+}
+
+// lissajous3D é uma curva de Lissajous em três eixos projetada no plano
+// A fase do frame gira a figura em torno do eixo vertical
+type lissajous3D struct {
+	fy, fz float64 // Frequências relativas de y e z (x tem frequência 1)
+	tilt   float64 // Inclinação da câmera em torno do eixo x (radianos)
+}
+
+func newLissajous3D(cfg Options) curve {
+	return lissajous3D{fy: cfg.Freq, fz: cfg.ZFreq, tilt: cfg.Tilt}
+}
+
+func (c lissajous3D) point(t, phase float64) (x, y float64) {
+	// Ponto no espaço, dentro do cubo [-1, 1]³
+	px := math.Sin(t)
+	py := math.Sin(c.fy * t)
+	pz := math.Sin(c.fz*t + math.Pi/2)
+	// Gira em torno do eixo vertical (y) pelo ângulo da fase
+	sin, cos := math.Sincos(phase)
+	rx := px*cos + pz*sin
+	rz := -px*sin + pz*cos
+	// Inclina em torno do eixo x e descarta a profundidade (projeção ortográfica)
+	sinT, cosT := math.Sincos(c.tilt)
+	ry := py*cosT - rz*sinT
+	// A diagonal do cubo mede sqrt(3): dividir garante que a projeção caiba em [-1, 1]
+	return rx / math.Sqrt(3), ry / math.Sqrt(3)
+}
+
+// decayed aplica um decaimento exponencial de amplitude a qualquer curva (flag -decay)
+type decayed struct {
+	curve
+	rate float64
+}
+
+func (d decayed) point(t, phase float64) (x, y float64) {
+	x, y = d.curve.point(t, phase)
+	k := math.Exp(-d.rate * t)
+	return x * k, y * k
+}
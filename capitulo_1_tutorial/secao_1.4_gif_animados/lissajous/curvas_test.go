@@ -0,0 +1,71 @@
+package lissajous
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestCurvesStayInBounds garante que todos os presets cabem em [-1, 1] nos dois eixos
+func TestCurvesStayInBounds(t *testing.T) {
+	for name := range curvePresets {
+		cfg := DefaultOptions()
+		cfg.Curve = name
+		cfg.Freq = 2.7
+		c, err := newCurve(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, phase := range []float64{0, 0.5, 3} {
+			for tt := 0.0; tt < cfg.Cycles*2*math.Pi; tt += 0.01 {
+				x, y := c.point(tt, phase)
+				if math.Abs(x) > 1 || math.Abs(y) > 1 {
+					t.Fatalf("%s: ponto (%g, %g) fora de [-1, 1] em t=%g", name, x, y, tt)
+				}
+			}
+		}
+	}
+}
+
+// TestLissajousCurveMatchesBook confere que o preset padrão é a fórmula do livro
+func TestLissajousCurveMatchesBook(t *testing.T) {
+	c := lissajousCurve{freq: 1.3}
+	x, y := c.point(2, 0.4)
+	if x != math.Sin(2) || y != math.Sin(2*1.3+0.4) {
+		t.Errorf("point(2, 0.4) = (%g, %g), esperado (%g, %g)", x, y, math.Sin(2), math.Sin(2*1.3+0.4))
+	}
+}
+
+// TestParseOscillators confere o formato freq:amp[:fase[:decay]]
+func TestParseOscillators(t *testing.T) {
+	oscs, err := ParseOscillators("2:1, 3.01:0.5:1.57:0.01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Oscillator{{2, 1, 0, 0}, {3.01, 0.5, 1.57, 0.01}}
+	if len(oscs) != len(want) || oscs[0] != want[0] || oscs[1] != want[1] {
+		t.Errorf("parseOscillators = %v, esperado %v", oscs, want)
+	}
+	nine := strings.TrimSuffix(strings.Repeat("1:1,", maxOscillators+1), ",")
+	for _, bad := range []string{"", "2", "1:2:3:4:5", "a:1", "1:Inf", "1:1:0:-0.1", nine} {
+		if _, err := ParseOscillators(bad); err == nil {
+			t.Errorf("parseOscillators(%q) deveria falhar", bad)
+		}
+	}
+}
+
+// TestWorkCountsOscillators confere que os osciladores informados entram no custo da amostra
+func TestWorkCountsOscillators(t *testing.T) {
+	cfg := DefaultOptions()
+	cfg.Curve = "harmonograph"
+	preset := cfg.Work()
+	cfg.XOsc = make(OscillatorList, maxOscillators)
+	cfg.YOsc = make(OscillatorList, maxOscillators)
+	if got := cfg.Work(); got <= 2*preset {
+		t.Errorf("%d osciladores: trabalho %g, esperado mais que o dobro do preset (%g)", 2*maxOscillators, got, preset)
+	}
+	cfg.Rotary = make(OscillatorList, maxOscillators+1)
+	if cfg.Validate() == nil {
+		t.Errorf("%d osciladores rotatórios deveriam ser recusados", maxOscillators+1)
+	}
+}
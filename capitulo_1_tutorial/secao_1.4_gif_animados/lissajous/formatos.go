@@ -0,0 +1,314 @@
+// Formatos de saída: os mesmos frames podem virar GIF, PNG animado (APNG)
+// ou uma sequência numerada de PNGs; o SVG desenha a curva vetorial exata
+package lissajous
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Format é o formato do arquivo gerado
+type Format int
+
+const (
+	FormatAuto   Format = iota // Deduzido da extensão de -o
+	FormatGIF                  // GIF animado (padrão do livro)
+	FormatAPNG                 // PNG animado
+	FormatPNGSeq               // Um PNG por frame: frame_000.png, frame_001.png...
+	FormatSVG                  // SVG animado com a curva vetorial
+)
+
+// Nomes aceitos em -format e no parâmetro format do servidor
+var formatNames = []string{"auto", "gif", "apng", "pngseq", "svg"}
+
+// Tipo MIME de cada formato que pode ser enviado pelo servidor
+var formatMIME = map[Format]string{
+	FormatGIF:  "image/gif",
+	FormatAPNG: "image/apng",
+	FormatSVG:  "image/svg+xml",
+}
+
+// MIME devolve o tipo MIME do formato, ou "" se ele não cabe em uma única resposta
+func (f Format) MIME() string {
+	return formatMIME[f]
+}
+
+func (f Format) String() string {
+	if f >= 0 && int(f) < len(formatNames) {
+		return formatNames[f]
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// Set implementa flag.Value, permitindo usar Format direto em flag.Var
+func (f *Format) Set(s string) error {
+	for i, name := range formatNames {
+		if s == name {
+			*f = Format(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("formato %q desconhecido (use %s)", s, strings.Join(formatNames, ", "))
+}
+
+// ResolveFormat troca FormatAuto pelo formato sugerido pelo caminho de saída:
+// um "%" (ex.: frame_%03d.png) indica sequência; .png/.apng viram APNG; .svg vira SVG
+func ResolveFormat(f Format, output string) Format {
+	if f != FormatAuto {
+		return f
+	}
+	if strings.Contains(output, "%") {
+		return FormatPNGSeq
+	}
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".png", ".apng":
+		return FormatAPNG
+	case ".svg":
+		return FormatSVG
+	}
+	return FormatGIF
+}
+
+// Distância mínima (em pixels) entre vértices das polylines do SVG: o traço vetorial
+// é suavizado pelo navegador, então vértices mais espaçados que no modo lines bastam
+const svgMinSegment = 2
+
+// pngSignature são os 8 bytes que abrem todo arquivo PNG
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngChunk é um bloco de um arquivo PNG: tipo de 4 letras e dados
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// readPNGChunks separa os blocos de um PNG codificado por image/png
+func readPNGChunks(b []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil, errors.New("apng: assinatura PNG ausente")
+	}
+	b = b[len(pngSignature):]
+	var chunks []pngChunk
+	for len(b) >= 12 {
+		n := binary.BigEndian.Uint32(b)
+		if uint64(n)+12 > uint64(len(b)) {
+			return nil, errors.New("apng: bloco PNG truncado")
+		}
+		chunks = append(chunks, pngChunk{string(b[4:8]), b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	return chunks, nil
+}
+
+// writePNGChunk escreve tamanho, tipo, dados e CRC de um bloco
+func writePNGChunk(w io.Writer, typ string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], typ)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	for _, part := range [][]byte{header[:], data, sum[:]} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeAPNG escreve os frames de anim como um PNG animado
+// Cada frame é codificado por image/png; os dados comprimidos (IDAT) são reaproveitados:
+// o primeiro frame mantém seus IDAT, os seguintes viram fdAT, e cada um ganha um fcTL
+// com tamanho e delay. Leitores sem suporte a APNG mostram apenas o primeiro frame.
+func encodeAPNG(out io.Writer, anim *gif.GIF) error {
+	if len(anim.Image) == 0 {
+		return errors.New("apng: nenhum frame")
+	}
+	// Um frame só (ex.: a folha estática de -sweep): PNG comum, sem blocos de animação
+	if len(anim.Image) == 1 {
+		return png.Encode(out, anim.Image[0])
+	}
+	if _, err := out.Write(pngSignature); err != nil {
+		return err
+	}
+	seq := uint32(0) // Número de sequência compartilhado por fcTL e fdAT
+	for i, img := range anim.Image {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return err
+		}
+		chunks, err := readPNGChunks(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		// fcTL: sequência, largura, altura, deslocamento x/y, delay (num/den), dispose e blend
+		b := img.Bounds()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], seq)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(b.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(b.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:], uint32(b.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:], uint32(b.Min.Y))
+		binary.BigEndian.PutUint16(fctl[20:], uint16(anim.Delay[i])) // Delay em centésimos,
+		binary.BigEndian.PutUint16(fctl[22:], 100)                   // como no GIF
+		seq++
+
+		for _, c := range chunks {
+			switch {
+			case i == 0 && c.typ == "IDAT":
+				// O fcTL do primeiro frame vem logo antes do primeiro IDAT
+				if fctl != nil {
+					if err := writePNGChunk(out, "fcTL", fctl); err != nil {
+						return err
+					}
+					fctl = nil
+				}
+				err = writePNGChunk(out, c.typ, c.data)
+			case i == 0 && c.typ == "IEND":
+				// O IEND só entra no fim do arquivo
+			case i == 0:
+				// IHDR, PLTE e tRNS do primeiro frame valem para a animação toda
+				err = writePNGChunk(out, c.typ, c.data)
+				if err == nil && c.typ == "IHDR" {
+					// acTL: quantidade de frames e de repetições (0 = infinito, como LoopCount)
+					actl := make([]byte, 8)
+					binary.BigEndian.PutUint32(actl[0:], uint32(len(anim.Image)))
+					binary.BigEndian.PutUint32(actl[4:], apngPlays(anim.LoopCount))
+					err = writePNGChunk(out, "acTL", actl)
+				}
+			case c.typ == "IDAT":
+				if fctl != nil {
+					if err := writePNGChunk(out, "fcTL", fctl); err != nil {
+						return err
+					}
+					fctl = nil
+				}
+				// fdAT: os mesmos dados do IDAT precedidos do número de sequência
+				fdat := make([]byte, 4+len(c.data))
+				binary.BigEndian.PutUint32(fdat, seq)
+				copy(fdat[4:], c.data)
+				seq++
+				err = writePNGChunk(out, "fdAT", fdat)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return writePNGChunk(out, "IEND", nil)
+}
+
+// apngPlays converte o LoopCount do GIF (0 = infinito, -1 = toca uma vez, n = repete n vezes)
+// no num_plays do APNG (0 = infinito, n = toca n vezes no total)
+func apngPlays(loopCount int) uint32 {
+	switch {
+	case loopCount == 0:
+		return 0
+	case loopCount < 0:
+		return 1
+	}
+	return uint32(loopCount) + 1
+}
+
+// encodeSVG escreve um SVG animado com a curva vetorial de cada frame
+// Cada frame é um grupo <g> que fica visível só durante o seu intervalo da animação;
+// trechos consecutivos da mesma cor viram uma única <polyline>
+func encodeSVG(out io.Writer, cfg Options) error {
+	c, err := newCurve(cfg)
+	if err != nil {
+		return err
+	}
+	// No SVG não há tons de anti-aliasing: as cores da curva são usadas cheias
+	style := cfg
+	style.Render = RenderPoints
+	sh := newShading(style)
+	colorOf := newColorer(cfg, sh.ncurve)
+
+	side := 2*cfg.Size + 1
+	fsize := float64(cfg.Size)
+	// Duração total em segundos; o SVG não aceita zero, então cada frame dura pelo menos 10ms
+	total := float64(max(cfg.Delay, 1)*cfg.NFrames) / 100
+
+	w := &svgWriter{w: out}
+	w.printf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", side, side, side, side)
+	w.printf(`<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(sh.palette[blackIndex]))
+	w.printf(`<g fill="none" stroke-width="%g" stroke-linejoin="round" stroke-linecap="round">`+"\n", cfg.Stroke)
+	phase := 0.0
+	for i := 0; i < cfg.NFrames; i++ {
+		// Leitores sem animação mostram só o primeiro frame
+		if i == 0 {
+			w.printf("<g>\n")
+		} else {
+			w.printf(`<g visibility="hidden">` + "\n")
+		}
+		var (
+			points  []string
+			lastCol uint8
+			lastX   = math.Inf(1)
+			lastY   = math.Inf(1)
+		)
+		flush := func() {
+			if len(points) > 1 {
+				w.printf(`<polyline stroke="%s" points="%s"/>`+"\n", hexColor(sh.palette[sh.index(lastCol, 1)]), strings.Join(points, " "))
+			}
+		}
+		for t := 0.0; t < cfg.Cycles*2*math.Pi; t += cfg.Res {
+			x, y := c.point(t, phase)
+			col := colorOf(i, t, x, y)
+			px, py := fsize+x*fsize, fsize+y*fsize
+			// Amostras a menos de svgMinSegment pixels do último vértice são puladas
+			if col == lastCol && math.Hypot(px-lastX, py-lastY) < svgMinSegment {
+				continue
+			}
+			p := fmt.Sprintf("%.1f,%.1f", px, py)
+			if col != lastCol {
+				// Troca de cor: fecha a polyline atual e começa outra no mesmo ponto,
+				// para que o traço continue sem falhas
+				flush()
+				if len(points) > 0 {
+					points = []string{points[len(points)-1]}
+				}
+				lastCol = col
+			}
+			points = append(points, p)
+			lastX, lastY = px, py
+		}
+		flush()
+		// Visibilidade discreta: o grupo aparece apenas entre i/n e (i+1)/n da animação
+		if cfg.NFrames > 1 {
+			values, keyTimes := "hidden;visible;hidden", fmt.Sprintf("0;%g;%g", float64(i)/float64(cfg.NFrames), float64(i+1)/float64(cfg.NFrames))
+			if i == 0 {
+				values, keyTimes = "visible;hidden", fmt.Sprintf("0;%g", 1/float64(cfg.NFrames))
+			}
+			w.printf(`<animate attributeName="visibility" values="%s" keyTimes="%s" dur="%gs" calcMode="discrete" repeatCount="indefinite"/>`+"\n", values, keyTimes, total)
+		}
+		w.printf("</g>\n")
+		phase += cfg.PhaseStep
+	}
+	w.printf("</g>\n</svg>\n")
+	return w.err
+}
+
+// svgWriter guarda o primeiro erro de escrita, para não testar cada Fprintf
+type svgWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (s *svgWriter) printf(format string, args ...any) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, args...)
+	}
+}
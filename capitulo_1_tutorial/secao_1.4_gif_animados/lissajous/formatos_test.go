@@ -0,0 +1,161 @@
+package lissajous
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"testing"
+)
+
+// TestResolveFormat confere a dedução do formato pela extensão de -o
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		format Format
+		output string
+		want   Format
+	}{
+		{FormatAuto, "lissajous.gif", FormatGIF},
+		{FormatAuto, "-", FormatGIF},
+		{FormatAuto, "curva.PNG", FormatAPNG},
+		{FormatAuto, "curva.apng", FormatAPNG},
+		{FormatAuto, "frames/f_%03d.png", FormatPNGSeq},
+		{FormatAuto, "curva.svg", FormatSVG},
+		{FormatSVG, "curva.gif", FormatSVG},
+	}
+	for _, tt := range tests {
+		if got := ResolveFormat(tt.format, tt.output); got != tt.want {
+			t.Errorf("resolveFormat(%v, %q) = %v, esperado %v", tt.format, tt.output, got, tt.want)
+		}
+	}
+}
+
+// TestEncodeAPNG confere a estrutura do APNG e que o primeiro frame continua legível como PNG comum
+func TestEncodeAPNG(t *testing.T) {
+	cfg := smallConfig()
+	cfg.Freq = 1.5
+	anim, err := Animate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := encodeAPNG(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := readPNGChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	var fctl, seqs []uint32 // fcTL encontrados e números de sequência de fcTL/fdAT, na ordem
+	for _, c := range chunks {
+		types = append(types, c.typ)
+		switch c.typ {
+		case "acTL":
+			if n := binary.BigEndian.Uint32(c.data); n != uint32(cfg.NFrames) {
+				t.Errorf("acTL anuncia %d frames, esperados %d", n, cfg.NFrames)
+			}
+		case "fcTL":
+			fctl = append(fctl, binary.BigEndian.Uint32(c.data))
+			seqs = append(seqs, binary.BigEndian.Uint32(c.data))
+			if d := binary.BigEndian.Uint16(c.data[20:]); d != uint16(cfg.Delay) {
+				t.Errorf("fcTL com delay %d, esperado %d", d, cfg.Delay)
+			}
+		case "fdAT":
+			seqs = append(seqs, binary.BigEndian.Uint32(c.data))
+		}
+	}
+	if types[0] != "IHDR" || types[1] != "acTL" || types[len(types)-1] != "IEND" {
+		t.Errorf("ordem dos blocos inesperada: %v", types)
+	}
+	if len(fctl) != cfg.NFrames {
+		t.Errorf("%d blocos fcTL, esperados %d", len(fctl), cfg.NFrames)
+	}
+	for i, s := range seqs {
+		if s != uint32(i) {
+			t.Fatalf("números de sequência fora de ordem: %v", seqs)
+		}
+	}
+
+	// image/png ignora os blocos de animação e decodifica o primeiro frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := anim.Image[0]
+	b := first.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, _ := img.At(x, y).RGBA()
+			r2, g2, b2, _ := first.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				t.Fatalf("pixel (%d, %d) difere do primeiro frame", x, y)
+			}
+		}
+	}
+}
+
+// TestEncodeSVG confere que o SVG é XML válido com um grupo por frame
+func TestEncodeSVG(t *testing.T) {
+	cfg := smallConfig()
+	cfg.Freq = 1.5
+	cfg.ColorMode = ColorByT
+	var buf bytes.Buffer
+	if err := encodeSVG(&buf, cfg); err != nil {
+		t.Fatal(err)
+	}
+	groups, animations := 0, 0
+	dec := xml.NewDecoder(&buf)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("SVG inválido: %v", err)
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			switch el.Name.Local {
+			case "g":
+				groups++
+			case "animate":
+				animations++
+			}
+		}
+	}
+	// Um grupo externo com o estilo do traço + um por frame
+	if groups != cfg.NFrames+1 || animations != cfg.NFrames {
+		t.Errorf("%d grupos e %d animações, esperados %d e %d", groups, animations, cfg.NFrames+1, cfg.NFrames)
+	}
+}
+
+// TestRenderSVGTrail confere que o rastro, que o SVG não tem como desenhar, é recusado
+func TestRenderSVGTrail(t *testing.T) {
+	cfg := smallConfig()
+	cfg.Format = FormatSVG
+	cfg.Trail = 2
+	var buf bytes.Buffer
+	if err := Render(&buf, cfg); err == nil || buf.Len() != 0 {
+		t.Errorf("svg com trail: erro %v e %d bytes, esperado erro sem escrita", err, buf.Len())
+	}
+	cfg.Format = FormatAPNG
+	if err := Render(&buf, cfg); err != nil {
+		t.Errorf("apng com trail: %v", err)
+	}
+}
+
+// TestRenderOptimizeFormats confere que Optimize, que só existe para GIF, é recusada nos
+// demais formatos
+func TestRenderOptimizeFormats(t *testing.T) {
+	for _, f := range []Format{FormatAuto, FormatGIF, FormatAPNG, FormatPNGSeq, FormatSVG} {
+		cfg := smallConfig()
+		cfg.Format = f
+		cfg.Optimize = true
+		err := cfg.Validate()
+		if ok := f == FormatAuto || f == FormatGIF; (err == nil) != ok {
+			t.Errorf("%v com optimize: Validate = %v, esperado ok = %v", f, err, ok)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Package lissajous gera animações de figuras de Lissajous (seção 1.4 do livro)
+// e das curvas derivadas delas: harmonógrafos, Lissajous 3D, osciloscópio XY com
+// áudio e folhas de contato. O programa gif_animados é uma interface de linha de
+// comando e HTTP para este pacote
+//
+// Uso mínimo:
+//
+//	opts := lissajous.DefaultOptions()
+//	opts.Freq = 1.5
+//	err := lissajous.Render(w, opts) // GIF animado em w
+package lissajous
+
+import (
+	"errors"      // Criação de erros de validação
+	"fmt"         // Formatação das mensagens de erro
+	"image"       // Tipos básicos para trabalhar com imagens
+	"image/color" // Definição de cores
+	"image/gif"   // Codificação/decodificação de arquivos GIF
+	"io"          // Interface para operações de I/O
+	"math"        // Funções matemáticas (Sin, Pi, etc)
+	"runtime"     // Quantidade de CPUs (padrão de Workers)
+	"strings"     // Lista dos valores aceitos nas mensagens de erro
+	"sync"        // Espera pelos workers que desenham os frames
+)
+
+// Limites aceitos para os parâmetros - evitam animações que esgotariam a memória
+const (
+	maxCycles  = 1000    // Máximo de oscilações do oscilador x
+	maxSize    = 2000    // Canvas máximo de 4001x4001 pixels
+	maxFrames  = 1000    // Máximo de frames na animação
+	maxDelay   = 65535   // O GIF guarda o delay em 16 bits (unidades de 10ms)
+	maxSamples = 1e8     // Máximo de pontos calculados por frame (cycles*2*Pi/res)
+	maxPixels  = 1 << 28 // Máximo de pixels somando todos os frames (~256 MB de imagens paletizadas)
+	maxColors  = 256     // Uma paleta GIF tem no máximo 256 cores
+	maxStroke  = 50      // Largura máxima do traço em pixels
+	maxWorkers = 256     // Máximo de goroutines desenhando frames ao mesmo tempo
+	maxTrail   = 64      // Máximo de frames anteriores visíveis no rastro
+)
+
+// Options reúne os parâmetros da animação, que no livro eram constantes fixas no código
+// O valor zero não é utilizável: comece de DefaultOptions e altere o que precisar
+type Options struct {
+	Cycles    float64        // Número de oscilações completas do oscilador x
+	Res       float64        // Resolução angular (menor = mais suave)
+	Size      int            // Tamanho da imagem (canvas será 2*size+1 x 2*size+1)
+	NFrames   int            // Número de frames na animação
+	Delay     int            // Delay entre frames em unidades de 10ms
+	Freq      float64        // Frequência relativa do oscilador y
+	PhaseStep float64        // Incremento de fase entre frames (faz a figura girar)
+	Palette   []color.Color  // [0]=fundo; as demais cores desenham a curva
+	ColorMode ColorMode      // Como as cores da curva são aplicadas (ver paleta.go)
+	Render    RenderMode     // Pontos isolados ou segmentos com anti-aliasing (ver rasterizacao.go)
+	Stroke    float64        // Largura do traço em pixels no modo lines
+	Curve     string         // Preset de curva (ver curvas.go)
+	XOsc      OscillatorList // Osciladores do eixo x do harmonógrafo (vazio = preset)
+	YOsc      OscillatorList // Osciladores do eixo y do harmonógrafo (vazio = preset)
+	Rotary    OscillatorList // Pêndulos rotatórios do harmonógrafo (vazio = preset)
+	Decay     float64        // Decaimento exponencial de amplitude aplicado a qualquer curva
+	ZFreq     float64        // Frequência relativa do eixo z (curva lissajous3d)
+	Tilt      float64        // Inclinação da câmera em radianos (curva lissajous3d)
+	Audio     *AudioSignal   // Áudio estéreo desenhado no lugar da curva (-wav, ver audio.go)
+	Sweep     RatioList      // Razões de frequência da folha de contato (vazio = uma curva só, ver varredura.go)
+	Offsets   []float64      // Defasagens das colunas da folha de contato, em múltiplos de Pi
+	Format    Format         // Formato gerado por Render (FormatAuto = GIF, ver formatos.go)
+	Trail     int            // Frames anteriores que continuam visíveis, apagando aos poucos (0 = sem rastro)
+	Workers   int            // Frames desenhados em paralelo (0 = um por CPU)
+	Optimize  bool           // Grava no GIF só o trecho de cada frame que mudou (ver otimizacao.go)
+}
+
+// Stats são as medidas da animação gravada por RenderStats
+type Stats struct {
+	Bytes            int64 // Bytes escritos em out
+	UnoptimizedBytes int64 // Com Optimize, o tamanho que o GIF teria sem a otimização (0 sem Optimize)
+}
+
+// DefaultOptions devolve os valores originais do livro
+// A frequência fica zerada: o programa gif_animados a sorteia quando -freq não é informada
+func DefaultOptions() Options {
+	return Options{
+		Cycles:    5,
+		Res:       0.001,
+		Size:      100,
+		NFrames:   64,
+		Delay:     8,
+		PhaseStep: 0.1,
+		Palette:   palette,
+		Stroke:    1,
+		Curve:     "lissajous",
+		ZFreq:     2,
+		Tilt:      0.4,
+		Offsets:   []float64{0, 0.125, 0.25, 0.375, 0.5},
+	}
+}
+
+// Validate confere se os parâmetros fazem sentido antes de gerar a animação
+// Retorna um erro descrevendo o primeiro parâmetro inválido encontrado
+func (c Options) Validate() error {
+	switch {
+	case !finite(c.Cycles) || c.Cycles <= 0 || c.Cycles > maxCycles:
+		return fmt.Errorf("cycles deve estar entre 0 (exclusivo) e %d, recebido %g", maxCycles, c.Cycles)
+	case !finite(c.Res) || c.Res <= 0:
+		return fmt.Errorf("res deve ser maior que zero, recebido %g", c.Res)
+	case c.Cycles*2*math.Pi/c.Res > maxSamples:
+		return fmt.Errorf("res %g é pequena demais para %g ciclos (mais de %g pontos por frame)", c.Res, c.Cycles, float64(maxSamples))
+	case c.Size < 1 || c.Size > maxSize:
+		return fmt.Errorf("size deve estar entre 1 e %d, recebido %d", maxSize, c.Size)
+	case c.NFrames < 1 || c.NFrames > maxFrames:
+		return fmt.Errorf("nframes deve estar entre 1 e %d, recebido %d", maxFrames, c.NFrames)
+	case (2*c.Size+1)*(2*c.Size+1)*c.NFrames > maxPixels:
+		return fmt.Errorf("size %d com %d frames ultrapassa o limite de %d pixels no total", c.Size, c.NFrames, maxPixels)
+	case c.Delay < 0 || c.Delay > maxDelay:
+		return fmt.Errorf("delay deve estar entre 0 e %d, recebido %d", maxDelay, c.Delay)
+	case !finite(c.Freq) || c.Freq < 0:
+		return fmt.Errorf("freq deve ser um número não negativo, recebido %g", c.Freq)
+	case !finite(c.PhaseStep):
+		return fmt.Errorf("phase deve ser um número finito, recebido %g", c.PhaseStep)
+	case !finite(c.Stroke) || c.Stroke <= 0 || c.Stroke > maxStroke:
+		return fmt.Errorf("stroke deve estar entre 0 (exclusivo) e %d, recebido %g", maxStroke, c.Stroke)
+	// Os enums só chegam fora da faixa por conversão direta (ex.: ColorMode(7)), nunca por Set
+	case c.ColorMode < 0 || int(c.ColorMode) >= len(colorModeNames):
+		return fmt.Errorf("colormode desconhecido: %v (use %s)", c.ColorMode, strings.Join(colorModeNames, ", "))
+	case c.Render < 0 || int(c.Render) >= len(renderModeNames):
+		return fmt.Errorf("render desconhecido: %v (use %s)", c.Render, strings.Join(renderModeNames, ", "))
+	case c.Format < 0 || int(c.Format) >= len(formatNames):
+		return fmt.Errorf("formato desconhecido: %v (use %s)", c.Format, strings.Join(formatNames, ", "))
+	case curvePresets[c.Curve] == nil:
+		return fmt.Errorf("curva %q desconhecida (use %s)", c.Curve, CurveNames())
+	case !finite(c.Decay) || c.Decay < 0:
+		return fmt.Errorf("decay deve ser um número não negativo, recebido %g", c.Decay)
+	case !finite(c.ZFreq) || !finite(c.Tilt):
+		return fmt.Errorf("zfreq e tilt devem ser números finitos, recebidos %g e %g", c.ZFreq, c.Tilt)
+	case len(c.Palette) < 2 || len(c.Palette) > maxColors:
+		return fmt.Errorf("a paleta deve ter entre 2 e %d cores (fundo + curva), recebidas %d", maxColors, len(c.Palette))
+	case len(c.XOsc) > maxOscillators || len(c.YOsc) > maxOscillators || len(c.Rotary) > maxOscillators:
+		return fmt.Errorf("no máximo %d osciladores por eixo, recebidos %d, %d e %d (xosc, yosc, rotary)",
+			maxOscillators, len(c.XOsc), len(c.YOsc), len(c.Rotary))
+	case c.Trail < 0 || c.Trail > maxTrail:
+		return fmt.Errorf("trail deve estar entre 0 e %d, recebido %d", maxTrail, c.Trail)
+	case c.Optimize && c.Format != FormatAuto && c.Format != FormatGIF:
+		// O recorte dos frames usa o disposal e a transparência do GIF (ver otimizacao.go)
+		return fmt.Errorf("-optimize só vale para GIF, não para o formato %v", c.Format)
+	case c.Trail > 0 && c.Format == FormatSVG:
+		// O SVG é um único traço que se move: não há frames anteriores para o rastro
+		return errors.New("-trail não funciona com o formato svg")
+	case c.Workers < 0 || c.Workers > maxWorkers:
+		return fmt.Errorf("workers deve estar entre 0 (um por CPU) e %d, recebido %d", maxWorkers, c.Workers)
+	case len(c.Sweep) > 0 && len(c.Sweep)*len(c.Offsets) > maxSweepCells:
+		return fmt.Errorf("a folha teria %d miniaturas (%d razões x %d defasagens), o máximo é %d", len(c.Sweep)*len(c.Offsets), len(c.Sweep), len(c.Offsets), maxSweepCells)
+	case len(c.Sweep) > 0 && newSheetLayout(c).w*newSheetLayout(c).h*c.NFrames > maxPixels:
+		return fmt.Errorf("a folha com size %d e %d frames ultrapassa o limite de %d pixels no total", c.Size, c.NFrames, maxPixels)
+	case len(c.Sweep) > 0 && (c.Audio != nil || c.Format == FormatSVG):
+		return errors.New("-sweep não funciona com -wav nem com o formato svg")
+	}
+	return nil
+}
+
+// trailFrames é quantos frames anteriores aparecem no rastro: no máximo os outros nframes-1
+func (c Options) trailFrames() int {
+	return min(c.Trail, c.NFrames-1)
+}
+
+// Work estima o trabalho de desenhar a animação inteira, para quem precisa limitar a CPU
+// gasta (como o modo servidor): amostras por frame x frames x curvas desenhadas por frame
+// (a do frame, as do rastro e as miniaturas de -sweep) x custo de cada amostra
+// O custo da amostra soma os senos da curva e os pixels que ela visita: 1 no modo points;
+// no modo lines, o retângulo que thickLine percorre, que cresce com o quadrado de Stroke
+// A unidade é arbitrária; na prática, cada uma leva de 5 a 20 ns
+func (c Options) Work() float64 {
+	samples := math.Ceil(c.Cycles * 2 * math.Pi / c.Res)
+	curves := float64(c.trailFrames() + 1)
+	if len(c.Sweep) > 0 {
+		curves *= float64(len(c.Sweep) * len(c.Offsets))
+	}
+	perSample := 2.0 // Os dois senos da curva do livro
+	switch c.Curve {
+	case "lissajous3d":
+		perSample = 3
+	case "harmonograph", "rotary":
+		perSample = 4 // Os presets têm 4 osciladores (ou 3, no rotary)
+		// Os informados substituem os do preset; os rotatórios calculam x e y
+		if n := len(c.XOsc) + len(c.YOsc) + 2*len(c.Rotary); n > 0 {
+			perSample = float64(n)
+		}
+	}
+	return samples * float64(c.NFrames) * curves * (perSample + c.Render.pixelsPerSample(c.Stroke))
+}
+
+// finite informa se x não é infinito nem NaN
+func finite(x float64) bool {
+	return !math.IsInf(x, 0) && !math.IsNaN(x)
+}
+
+// Render gera a animação descrita por opts e a escreve em out, no formato opts.Format
+// (FormatAuto gera GIF, como no livro). Devolve o primeiro erro encontrado: opções
+// inválidas (ver Validate) ou falha na codificação/escrita, como a de gif.EncodeAll
+// FormatPNGSeq grava vários arquivos e não é aceito aqui: use Animate e image/png
+func Render(out io.Writer, opts Options) error {
+	_, err := render(out, opts, false)
+	return err
+}
+
+// RenderStats é o Render que também devolve quantos bytes foram escritos e, com Optimize,
+// quantos seriam sem a otimização (o que custa codificar o GIF mais uma vez)
+func RenderStats(out io.Writer, opts Options) (Stats, error) {
+	return render(out, opts, true)
+}
+
+// render é o Render; com measure, preenche Stats.UnoptimizedBytes
+func render(out io.Writer, opts Options, measure bool) (Stats, error) {
+	if err := opts.Validate(); err != nil {
+		return Stats{}, err
+	}
+	var stats Stats
+	w := &countingWriter{w: out}
+	var err error
+	switch opts.Format {
+	case FormatSVG:
+		err = encodeSVG(w, opts)
+	case FormatAPNG:
+		var anim *gif.GIF
+		if anim, err = Animate(opts); err == nil {
+			err = encodeAPNG(w, anim)
+		}
+	case FormatPNGSeq:
+		err = errors.New("o formato pngseq grava vários arquivos e não pode ir para um único destino")
+	default:
+		stats.UnoptimizedBytes, err = encodeGIF(w, opts, measure)
+	}
+	stats.Bytes = w.n
+	return stats, err
+}
+
+// encodeGIF é a função lissajous do livro: gera a animação e a codifica em GIF
+// Os parâmetros vêm de cfg, que já deve ter passado por Validate. Com Optimize e measure,
+// devolve também o tamanho que o GIF teria sem a otimização
+func encodeGIF(out io.Writer, cfg Options, measure bool) (unoptimized int64, err error) {
+	anim, err := Animate(cfg)
+	if err != nil {
+		return 0, err
+	}
+	// Com -optimize, os frames são recortados antes de codificar (ver otimizacao.go)
+	if cfg.Optimize {
+		return encodeOptimized(out, anim, measure)
+	}
+	// Codifica toda a animação GIF e escreve no destino (arquivo)
+	return 0, gif.EncodeAll(out, anim)
+}
+
+// Animate gera os frames da animação, independente do formato de saída
+// gif.GIF serve de contêiner: frames, delays e LoopCount (ver formatos.go para APNG e PNG)
+// cfg já deve ter passado por Validate
+func Animate(cfg Options) (*gif.GIF, error) {
+	// Com -sweep, cada frame é uma folha com uma miniatura por combinação (ver varredura.go)
+	if len(cfg.Sweep) > 0 {
+		return animateSweep(cfg)
+	}
+	// Estrutura GIF: LoopCount=0 significa loop infinito
+	anim := gif.GIF{LoopCount: 0} //no livro encontra-se GIF{LoopCount: nframes} foi modificado para GIF{LoopCount: 0}
+	// Curva escolhida em -curve (ver curvas.go); a padrão é a do livro
+	c, err := newCurve(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Paleta dos frames e função que escolhe a cor de cada ponto (ver paleta.go)
+	sh := newShading(cfg)
+	colorOf := newColorer(cfg, sh.ncurve)
+	phases := framePhases(cfg)
+
+	// Cada frame vai para a sua posição do slice: a ordem final não depende dos workers
+	anim.Image = make([]*image.Paletted, cfg.NFrames)
+	anim.Delay = make([]int, cfg.NFrames)
+	// Define o retângulo da imagem: de (0,0) até (2*size+1, 2*size+1)
+	rect := image.Rect(0, 0, 2*cfg.Size+1, 2*cfg.Size+1)
+	parallel(workerCount(cfg, cfg.NFrames), cfg.NFrames, func(i int) {
+		// Cria uma imagem paletizada (usa índices da paleta ao invés de RGB direto)
+		img := image.NewPaletted(rect, sh.palette)
+		// Desenha a curva deste frame e converte a cobertura em tons da paleta
+		renderFrame(cfg, c, colorOf, i, phases).paint(img, sh)
+		anim.Image[i] = img
+		anim.Delay[i] = cfg.Delay
+	})
+	return &anim, nil
+}
+
+// framePhases devolve a fase de cada frame
+// A única dependência entre frames é a fase, que o livro acumula frame a frame
+// Calculada antes (na mesma ordem de somas), cada frame pode ser desenhado em qualquer ordem
+func framePhases(cfg Options) []float64 {
+	phases := make([]float64, cfg.NFrames)
+	// Diferença de fase entre os osciladores x e y
+	phase := 0.0
+	for i := range phases {
+		phases[i] = phase
+		// Incrementa a fase para o próximo frame (faz a figura girar)
+		phase += cfg.PhaseStep
+	}
+	return phases
+}
+
+// parallel chama do(i) para i de 0 a n-1 em um pool de workers goroutines
+// Pool limitado: no máximo workers chamadas (e canvases) ao mesmo tempo
+func parallel(workers, n int, do func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				do(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// workerCount devolve quantas goroutines desenham: Workers, ou uma por CPU
+// se for 0, nunca mais que a quantidade de tarefas
+func workerCount(cfg Options, tasks int) int {
+	n := cfg.Workers
+	if n == 0 {
+		n = runtime.NumCPU()
+	}
+	return max(1, min(n, tasks))
+}
+
+// renderFrame desenha o frame i em um canvas novo: a curva do frame e, com Trail,
+// as curvas dos frames anteriores com brilho menor, como o fósforo de um osciloscópio
+// phases traz a fase de todos os frames da animação
+func renderFrame(cfg Options, c curve, colorOf colorer, i int, phases []float64) *canvas {
+	cv := newCanvas(2*cfg.Size+1, 2*cfg.Size+1)
+	// A animação repete: o rastro do frame 0 vem dos últimos frames (nunca do próprio frame)
+	ages := cfg.trailFrames()
+	// Do mais antigo para o atual: com cobertura igual, o traço desenhado por último vence
+	for age := ages; age >= 0; age-- {
+		j := (i - age + cfg.NFrames) % cfg.NFrames
+		// O brilho cai linearmente: o frame atual tem 1, o mais antigo 1/(ages+1)
+		cv.intensity = float64(ages+1-age) / float64(ages+1)
+		drawCurve(cv, cfg, c, colorOf, j, phases[j])
+	}
+	return cv
+}
+
+// drawCurve desenha no canvas a curva do frame i (com a fase phase)
+func drawCurve(cv *canvas, cfg Options, c curve, colorOf colorer, i int, phase float64) {
+	// Atalhos locais para manter as fórmulas iguais às do livro
+	size := cfg.Size
+	fsize := float64(size)
+	p := pen{cv: cv, width: cfg.Stroke}
+	// Loop que desenha a curva para este frame
+	for t := 0.0; t < cfg.Cycles*2*math.Pi; t += cfg.Res {
+		// Calcula o ponto da curva (na do livro: x=sin(t), y=sin(t*freq+phase))
+		x, y := c.point(t, phase)
+		// Cor escolhida pelo modo de coloração
+		col := colorOf(i, t, x, y)
+		if cfg.Render == RenderLines {
+			// Converte [-1,1] para [0, 2*size] sem arredondar: o anti-aliasing usa a fração
+			p.lineTo(fsize+x*fsize, fsize+y*fsize, col)
+			continue
+		}
+		// Define o pixel na posição calculada
+		// size+int(x*fsize+0.5): converte coordenada [-1,1] para [0, 2*size]
+		// +0.5 faz arredondamento correto ao converter float para int
+		cv.plot(size+int(x*fsize+0.5), size+int(y*fsize+0.5), 1, col)
+	}
+	p.finish()
+}
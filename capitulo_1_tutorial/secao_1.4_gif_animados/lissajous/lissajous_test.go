@@ -0,0 +1,155 @@
+package lissajous
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// TestAnimateWorkers garante que a quantidade de workers não muda nenhum byte do GIF
+func TestAnimateWorkers(t *testing.T) {
+	cfg := smallConfig()
+	cfg.Freq = 1.5
+	cfg.NFrames = 9
+	cfg.ColorMode = ColorByFrame
+	var want []byte
+	for _, workers := range []int{1, 2, 4, 16} {
+		cfg.Workers = workers
+		var buf bytes.Buffer
+		if _, err := encodeGIF(&buf, cfg, false); err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		if want == nil {
+			want = buf.Bytes()
+			continue
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("workers=%d gerou um GIF diferente de workers=1", workers)
+		}
+	}
+}
+
+// TestTrail confere o rastro: cada pixel do frame mostra o frame mais recente que passou
+// por ele, com o brilho da sua idade (3 de 3 no atual, 2 de 3 no anterior, 1 de 3 antes dele)
+func TestTrail(t *testing.T) {
+	cfg := smallConfig()
+	cfg.Freq = 1.5
+	plain, err := Animate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Trail = 2
+	trail, err := Animate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const levels = 3 // trail+1 tons por cor no modo points
+	lit := func(frame, i int) bool {
+		return plain.Image[(frame+cfg.NFrames)%cfg.NFrames].Pix[i] != blackIndex
+	}
+	for f, img := range trail.Image {
+		for i, p := range img.Pix {
+			want := 0
+			switch {
+			case lit(f, i):
+				want = 3
+			case lit(f-1, i):
+				want = 2
+			case lit(f-2, i):
+				want = 1
+			}
+			got := 0
+			if p != blackIndex {
+				got = (int(p)-1)%levels + 1
+			}
+			if got != want {
+				t.Fatalf("frame %d, pixel %d: nível %d, esperado %d", f, i, got, want)
+			}
+		}
+	}
+}
+
+// BenchmarkAnimate compara o desenho serial (1 worker) com o pool em uma animação grande
+// O ganho aparece em máquinas com vários núcleos:
+//
+//	go test -run '^$' -bench Animate -benchtime 3x
+func BenchmarkAnimate(b *testing.B) {
+	cfg := DefaultOptions()
+	cfg.Freq = 1.5
+	cfg.Size = 400
+	cfg.NFrames = 64
+	cfg.Render = RenderLines
+	counts := []int{1, 2, 4, runtime.NumCPU()}
+	for i, n := range counts {
+		if i > 0 && n <= counts[i-1] {
+			break // NumCPU repetido ou menor que 4
+		}
+		cfg.Workers = n
+		b.Run(fmt.Sprintf("workers=%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := Animate(cfg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// smallConfig reduz a animação padrão para os testes rodarem rápido
+func smallConfig() Options {
+	cfg := DefaultOptions()
+	cfg.Size = 40
+	cfg.NFrames = 6
+	return cfg
+}
+
+// TestWork confere que a estimativa cresce com o rastro, o traço e os frames
+func TestWork(t *testing.T) {
+	base := smallConfig()
+	base.Render = RenderLines
+	w := base.Work()
+
+	trail := base
+	trail.Trail = 3
+	if got := trail.Work(); got != 4*w {
+		t.Errorf("trail 3: trabalho %g, esperado 4x %g", got, w)
+	}
+	frames := base
+	frames.NFrames *= 2
+	if got := frames.Work(); got != 2*w {
+		t.Errorf("nframes x2: trabalho %g, esperado 2x %g", got, w)
+	}
+	wide := base
+	wide.Stroke = 40
+	if got := wide.Work(); got < 100*w {
+		t.Errorf("stroke 40: trabalho %g, esperado mais de 100x %g", got, w)
+	}
+}
+
+// TestValidateEnums confere que valores fora da faixa dos enums são recusados e que
+// String não entra em pânico com eles
+func TestValidateEnums(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Options)
+		str  fmt.Stringer
+	}{
+		{"ColorMode(-1)", func(c *Options) { c.ColorMode = -1 }, ColorMode(-1)},
+		{"ColorMode(4)", func(c *Options) { c.ColorMode = 4 }, ColorMode(4)},
+		{"RenderMode(-1)", func(c *Options) { c.Render = -1 }, RenderMode(-1)},
+		{"RenderMode(2)", func(c *Options) { c.Render = 2 }, RenderMode(2)},
+		{"Format(-1)", func(c *Options) { c.Format = -1 }, Format(-1)},
+		{"Format(5)", func(c *Options) { c.Format = 5 }, Format(5)},
+	}
+	for _, tt := range tests {
+		if got := tt.str.String(); got != tt.name {
+			t.Errorf("String() = %q, esperado %q", got, tt.name)
+		}
+		cfg := smallConfig()
+		tt.edit(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: Validate aceitou", tt.name)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Otimização do GIF: em vez de gravar cada frame inteiro, grava só o retângulo que
+// mudou em relação ao frame anterior, deixando o restante da tela como estava
+package lissajous
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"io"
+)
+
+// optimizeGIF recorta cada frame (a partir do segundo) para o menor retângulo que contém
+// todos os pixels diferentes do frame anterior
+// Disposal = DisposalNone faz o leitor manter na tela o que já foi desenhado, e o recorte
+// é pintado por cima na posição certa (os Bounds da imagem viram o offset do frame no GIF)
+// Se a paleta tiver espaço, ganha uma cor transparente: dentro do recorte, os pixels que
+// não mudaram podem virar transparentes, formando sequências longas que o LZW comprime bem
+// Os frames de anim precisam estar inteiros e com a mesma paleta (como Animate os gera)
+func optimizeGIF(anim *gif.GIF) {
+	if len(anim.Image) == 0 {
+		return
+	}
+	pal := anim.Image[0].Palette
+	transparent := -1
+	if len(pal) < maxColors {
+		// Cópia: as imagens originais continuam com a paleta sem transparência
+		pal = append(pal[:len(pal):len(pal)], color.Transparent)
+		transparent = len(pal) - 1
+	}
+
+	anim.Disposal = make([]byte, len(anim.Image))
+	// prev é o frame anterior inteiro, como aparece na tela do leitor
+	prev := anim.Image[0]
+	for i, cur := range anim.Image {
+		anim.Disposal[i] = gif.DisposalNone
+		if i == 0 {
+			// O primeiro frame continua inteiro; só a paleta muda, para todos os frames
+			// compartilharem a tabela de cores global
+			first := *cur
+			first.Palette = pal
+			anim.Image[0] = &first
+			continue
+		}
+		r := changedBounds(prev, cur)
+		if r.Empty() {
+			// Frame igual ao anterior: o GIF não aceita frame vazio, grava 1 pixel
+			r = image.Rect(cur.Rect.Min.X, cur.Rect.Min.Y, cur.Rect.Min.X+1, cur.Rect.Min.Y+1)
+		}
+		// Recorte com os pixels do frame atual, e outro com os que não mudaram transparentes
+		// Qual comprime melhor depende da figura: fica o menor
+		crop := image.NewPaletted(r, pal)
+		masked := image.NewPaletted(r, pal)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				p := cur.Pix[cur.PixOffset(x, y)]
+				crop.Pix[crop.PixOffset(x, y)] = p
+				if transparent >= 0 && p == prev.Pix[prev.PixOffset(x, y)] {
+					p = uint8(transparent)
+				}
+				masked.Pix[masked.PixOffset(x, y)] = p
+			}
+		}
+		if transparent >= 0 && encodedSize(masked) < encodedSize(crop) {
+			crop = masked
+		}
+		anim.Image[i] = crop
+		prev = cur
+	}
+}
+
+// encodedSize é o tamanho de img codificada sozinha como GIF
+func encodedSize(img *image.Paletted) int64 {
+	var c countingWriter
+	// Com uma imagem paletizada e um countingWriter, Encode não tem como falhar
+	gif.Encode(&c, img, nil)
+	return c.n
+}
+
+// changedBounds devolve o menor retângulo com todos os pixels em que a e b diferem
+// (vazio se os frames forem iguais); a e b precisam ter os mesmos Bounds
+func changedBounds(a, b *image.Paletted) image.Rectangle {
+	var r image.Rectangle
+	bounds := b.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		ia, ib := a.PixOffset(bounds.Min.X, y), b.PixOffset(bounds.Min.X, y)
+		for x := bounds.Min.X; x < bounds.Max.X; x, ia, ib = x+1, ia+1, ib+1 {
+			if a.Pix[ia] != b.Pix[ib] {
+				// Union com um retângulo vazio devolve o outro retângulo
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+// encodeOptimized otimiza anim e grava o GIF em out; com measure, devolve o tamanho
+// que o GIF teria sem a otimização
+func encodeOptimized(out io.Writer, anim *gif.GIF, measure bool) (unoptimized int64, err error) {
+	if measure {
+		// O tamanho "antes" exige codificar a versão sem otimização (sem gravar os bytes)
+		var before countingWriter
+		if err := gif.EncodeAll(&before, anim); err != nil {
+			return 0, err
+		}
+		unoptimized = before.n
+	}
+	optimizeGIF(anim)
+	return unoptimized, gif.EncodeAll(out, anim)
+}
+
+// countingWriter conta os bytes escritos; com w nil, apenas descarta
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.w == nil {
+		c.n += int64(len(p))
+		return len(p), nil
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,111 @@
+package lissajous
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/gif"
+	"testing"
+)
+
+// TestOptimizeGIF decodifica o GIF otimizado, recompõe a tela frame a frame (como um
+// navegador faria com DisposalNone) e confere que cada frame é igual ao original
+func TestOptimizeGIF(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*Options)
+	}{
+		{"alternate", func(c *Options) {}},
+		{"lines", func(c *Options) { c.Render = RenderLines; c.ColorMode = ColorByT }},
+		{"parada", func(c *Options) { c.PhaseStep = 0; c.ColorMode = ColorByT }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := smallConfig()
+			cfg.Freq = 1.5
+			tt.edit(&cfg)
+			want, err := Animate(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			anim, err := Animate(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			unoptimized, err := encodeOptimized(&buf, anim, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if unoptimized <= 0 || int64(buf.Len()) > unoptimized {
+				t.Errorf("%d bytes otimizados, %d sem otimização", buf.Len(), unoptimized)
+			}
+
+			got, err := gif.DecodeAll(&buf)
+			if err != nil {
+				t.Fatalf("GIF otimizado não decodifica: %v", err)
+			}
+			if len(got.Image) != len(want.Image) {
+				t.Fatalf("%d frames, esperados %d", len(got.Image), len(want.Image))
+			}
+			screen := image.NewRGBA(want.Image[0].Bounds())
+			for i, frame := range got.Image {
+				if got.Disposal[i] != gif.DisposalNone {
+					t.Errorf("frame %d: disposal %d, esperado DisposalNone", i, got.Disposal[i])
+				}
+				// draw.Over: pixels transparentes deixam aparecer o que já estava na tela
+				draw.Draw(screen, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+				if p := firstDiff(screen, want.Image[i]); p != nil {
+					t.Fatalf("frame %d difere do original no pixel %v", i, *p)
+				}
+			}
+		})
+	}
+}
+
+// TestOptimizeGIFStatic confere que frames iguais ao anterior viram um único pixel
+func TestOptimizeGIFStatic(t *testing.T) {
+	cfg := smallConfig()
+	cfg.Freq = 1.5
+	cfg.PhaseStep = 0
+	cfg.ColorMode = ColorByT
+	anim, err := Animate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	optimizeGIF(anim)
+	for i, img := range anim.Image[1:] {
+		if b := img.Bounds(); b.Dx() != 1 || b.Dy() != 1 {
+			t.Errorf("frame %d: recorte %v, esperado 1x1", i+1, b)
+		}
+	}
+}
+
+// TestChangedBounds confere o retângulo da diferença entre dois frames
+func TestChangedBounds(t *testing.T) {
+	a := image.NewPaletted(image.Rect(0, 0, 10, 10), palette)
+	b := image.NewPaletted(image.Rect(0, 0, 10, 10), palette)
+	if r := changedBounds(a, b); !r.Empty() {
+		t.Errorf("frames iguais: %v, esperado vazio", r)
+	}
+	b.SetColorIndex(2, 3, 1)
+	b.SetColorIndex(7, 5, 2)
+	if r, want := changedBounds(a, b), image.Rect(2, 3, 8, 6); r != want {
+		t.Errorf("changedBounds = %v, esperado %v", r, want)
+	}
+}
+
+// firstDiff devolve o primeiro pixel em que as cores de a e b diferem (nil se iguais)
+func firstDiff(a, b image.Image) *image.Point {
+	bounds := b.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r1, g1, b1, _ := a.At(x, y).RGBA()
+			r2, g2, b2, _ := b.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 {
+				return &image.Point{X: x, Y: y}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Subsistema de paletas: paletas com nome, listas de cores em hexadecimal
+// e modos de coloração que transformam as cores da curva em um gradiente
+package lissajous
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Paleta de cores padrão: [0]=preto (fundo), [1]=verde, [2]=laranja
+var palette = []color.Color{color.Black, color.RGBA{0x00, 0xff, 0x00, 0xff}, color.RGBA{0xff, 0x7f, 0x00, 0xff}}
+
+// Índice da cor de fundo em qualquer paleta; as demais cores desenham a curva
+const blackIndex = 0
+
+// Quantidade de tons gerados entre as cores da curva nos modos de gradiente
+const gradientSteps = 64
+
+// namedPalettes são as paletas que podem ser escolhidas pelo nome em -palette
+// Em todas, a primeira cor é o fundo
+var namedPalettes = map[string]string{
+	"classico":  "000000,00ff00,ff7f00",
+	"fosforo":   "000000,004010,00ff66",
+	"arco-iris": "000000,ff0000,ff7f00,ffff00,00ff00,0000ff,8b00ff",
+	"fogo":      "000000,800000,ff4000,ffbf00,ffff80",
+	"oceano":    "001020,0040ff,00c0ff,e0ffff",
+	"cinza":     "000000,404040,ffffff",
+}
+
+// PaletteNames lista os nomes aceitos, em ordem alfabética (para mensagens de ajuda)
+func PaletteNames() string {
+	names := make([]string, 0, len(namedPalettes))
+	for name := range namedPalettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// ParsePalette aceita o nome de uma paleta ou uma lista de cores hexadecimais separadas por vírgula
+// Ex.: "000000,00ff00,ff7f00" reproduz a paleta padrão (a primeira cor é o fundo)
+func ParsePalette(s string) ([]color.Color, error) {
+	if hexList, ok := namedPalettes[s]; ok {
+		s = hexList
+	}
+	var colors []color.Color
+	for _, field := range strings.Split(s, ",") {
+		c, err := parseHexColor(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("%v (ou use uma paleta pelo nome: %s)", err, PaletteNames())
+		}
+		colors = append(colors, c)
+	}
+	if len(colors) < 2 {
+		return nil, fmt.Errorf("paleta %q precisa de pelo menos 2 cores (fundo + curva)", s)
+	}
+	return colors, nil
+}
+
+// parseHexColor converte "rrggbb" ou "#rrggbb" em uma cor opaca
+func parseHexColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("cor %q inválida: use o formato rrggbb", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("cor %q inválida: use o formato rrggbb", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xff}, nil
+}
+
+// ColorMode define como cada ponto da curva escolhe sua cor
+type ColorMode int
+
+const (
+	ColorAlternate ColorMode = iota // Uma cor por frame, alternando (comportamento do livro)
+	ColorByT                        // Gradiente ao longo do parâmetro t da curva
+	ColorByFrame                    // Gradiente ao longo dos frames da animação
+	ColorByRadius                   // Gradiente pela distância do ponto ao centro
+)
+
+// Nomes aceitos em -colormode e no parâmetro colormode do servidor
+var colorModeNames = []string{"alternate", "t", "frame", "radius"}
+
+func (m ColorMode) String() string {
+	if m >= 0 && int(m) < len(colorModeNames) {
+		return colorModeNames[m]
+	}
+	return fmt.Sprintf("ColorMode(%d)", int(m))
+}
+
+// Set implementa flag.Value, permitindo usar ColorMode direto em flag.Var
+func (m *ColorMode) Set(s string) error {
+	for i, name := range colorModeNames {
+		if s == name {
+			*m = ColorMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("modo de cor %q desconhecido (use %s)", s, strings.Join(colorModeNames, ", "))
+}
+
+// shading descreve a paleta final dos frames: [0]=fundo e, para cada uma das ncurve
+// cores da curva, levels tons que vão do quase apagado (nível 1) até a cor cheia
+// Os tons intermediários servem ao anti-aliasing (ver rasterizacao.go)
+type shading struct {
+	palette []color.Color
+	ncurve  int // Quantidade de cores da curva (índices 1..ncurve no colorer)
+	levels  int // Tons por cor da curva
+}
+
+// newShading monta a paleta dos frames a partir de cfg
+// No modo alternate as cores da curva são as da própria paleta; nos gradientes, elas
+// viram pontos de parada de um gradiente com até gradientSteps tons
+// O rastro (Trail) multiplica os tons: cada frame anterior visível ganha o seu brilho
+// Como o GIF aceita só 256 cores, ncurve*levels nunca passa de 255
+func newShading(cfg Options) shading {
+	bg := cfg.Palette[blackIndex]
+	levels := min(cfg.Render.levels()*(cfg.trailFrames()+1), maxColors-1)
+	colors := cfg.Palette[1:]
+	if cfg.ColorMode != ColorAlternate {
+		// Um rastro longo no modo lines pede 128 tons ou mais; sobram ao menos 2 tons de
+		// gradiente, senão o modo de cor não teria o que variar
+		levels = min(levels, (maxColors-1)/2)
+		colors = gradient(colors, min(gradientSteps, (maxColors-1)/levels))
+	}
+	// Paletas alternadas muito longas sacrificam tons de anti-aliasing
+	levels = max(1, min(levels, (maxColors-1)/len(colors)))
+
+	pal := []color.Color{bg}
+	for _, c := range colors {
+		for l := 1; l <= levels; l++ {
+			pal = append(pal, mix(bg, c, float64(l)/float64(levels)))
+		}
+	}
+	return shading{palette: pal, ncurve: len(colors), levels: levels}
+}
+
+// index converte a cor da curva col (1..ncurve) com cobertura cov (0..1) em índice da paleta
+func (s shading) index(col uint8, cov float32) uint8 {
+	if cov <= 0 {
+		return blackIndex
+	}
+	// A folga de 1e-4 absorve o arredondamento do float32: 2/3 guardado como float32
+	// vezes 3 dá 2.0000001, que sem ela subiria para o nível 3
+	level := int(math.Ceil(float64(cov)*float64(s.levels) - 1e-4))
+	level = max(1, min(level, s.levels))
+	return uint8(1 + (int(col)-1)*s.levels + level - 1)
+}
+
+// gradient interpola linearmente (em RGB) as cores stops em n tons
+// Com n = 1 o único tom é a primeira parada
+func gradient(stops []color.Color, n int) []color.Color {
+	colors := make([]color.Color, max(n, 0))
+	for i := range colors {
+		if len(stops) == 1 || n == 1 {
+			colors[i] = stops[0]
+			continue
+		}
+		// pos percorre [0, len(stops)-1]: a parte inteira escolhe o trecho, a fração mistura as cores
+		pos := float64(i) / float64(n-1) * float64(len(stops)-1)
+		k := int(pos)
+		if k >= len(stops)-1 {
+			k = len(stops) - 2
+		}
+		colors[i] = mix(stops[k], stops[k+1], pos-float64(k))
+	}
+	return colors
+}
+
+// mix combina as cores a e b: f=0 devolve a, f=1 devolve b
+func mix(a, b color.Color, f float64) color.RGBA {
+	ar, ag, ab, _ := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+	lerp := func(x, y uint32) uint8 {
+		return uint8((float64(x)*(1-f) + float64(y)*f) / 0x101)
+	}
+	return color.RGBA{lerp(ar, br), lerp(ag, bg), lerp(ab, bb), 0xff}
+}
+
+// hexColor formata a cor como "#rrggbb" (usado no SVG)
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// colorer escolhe a cor da curva (1..ncurve) para um ponto
+// frame é o número do frame, t o parâmetro da curva e (x, y) o ponto em [-1, 1]
+type colorer func(frame int, t, x, y float64) uint8
+
+// newColorer cria a função de coloração correspondente a ColorMode
+// ncurve é a quantidade de cores da curva (shading.ncurve); os índices vão de 1 a ncurve
+func newColorer(cfg Options, ncurve int) colorer {
+	// shade converte v em [0, 1] em uma das cores do gradiente
+	shade := func(v float64) uint8 {
+		v = math.Max(0, math.Min(1, v))
+		return uint8(1 + int(v*float64(ncurve-1)+0.5))
+	}
+	tmax := cfg.Cycles * 2 * math.Pi
+	switch cfg.ColorMode {
+	case ColorByT:
+		return func(_ int, t, _, _ float64) uint8 { return shade(t / tmax) }
+	case ColorByFrame:
+		return func(frame int, _, _, _ float64) uint8 {
+			if cfg.NFrames == 1 {
+				return shade(0)
+			}
+			return shade(float64(frame) / float64(cfg.NFrames-1))
+		}
+	case ColorByRadius:
+		// A maior distância possível do centro é a diagonal: sqrt(2)
+		return func(_ int, _, x, y float64) uint8 { return shade(math.Hypot(x, y) / math.Sqrt2) }
+	}
+	// Alterna entre as cores da curva a cada frame
+	// Com a paleta padrão: frames pares em laranja (2), ímpares em verde (1)
+	return func(frame int, _, _, _ float64) uint8 {
+		return uint8(1 + (frame+1)%ncurve)
+	}
+}
@@ -0,0 +1,99 @@
+package lissajous
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestParsePalette confere paletas pelo nome, listas hexadecimais e erros
+func TestParsePalette(t *testing.T) {
+	p, err := ParsePalette("classico")
+	if err != nil || len(p) != len(palette) {
+		t.Fatalf(`parsePalette("classico") = %v, %v`, p, err)
+	}
+	for i := range palette {
+		if color.RGBAModel.Convert(p[i]) != color.RGBAModel.Convert(palette[i]) {
+			t.Errorf("classico[%d] = %v, esperado %v", i, p[i], palette[i])
+		}
+	}
+
+	p, err = ParsePalette("#102030, ffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (color.RGBA{0x10, 0x20, 0x30, 0xff}); p[0] != want {
+		t.Errorf("fundo = %v, esperado %v", p[0], want)
+	}
+
+	for _, bad := range []string{"", "ffffff", "fff,000", "gggggg,000000", "inexistente"} {
+		if _, err := ParsePalette(bad); err == nil {
+			t.Errorf("parsePalette(%q) deveria falhar", bad)
+		}
+	}
+}
+
+// TestGradient confere que o gradiente começa e termina nas cores de parada
+func TestGradient(t *testing.T) {
+	stops := []color.Color{color.RGBA{0, 0, 0, 0xff}, color.RGBA{0xff, 0x80, 0x00, 0xff}}
+	g := gradient(stops, gradientSteps)
+	if len(g) != gradientSteps {
+		t.Fatalf("%d tons, esperados %d", len(g), gradientSteps)
+	}
+	if g[0] != stops[0] || g[len(g)-1] != stops[1] {
+		t.Errorf("extremos = %v e %v, esperados %v e %v", g[0], g[len(g)-1], stops[0], stops[1])
+	}
+}
+
+// TestColorerRange garante que todos os modos devolvem cores válidas da curva
+func TestColorerRange(t *testing.T) {
+	for _, mode := range colorModeNames {
+		cfg := smallConfig()
+		cfg.Freq = 1
+		if err := cfg.ColorMode.Set(mode); err != nil {
+			t.Fatal(err)
+		}
+		sh := newShading(cfg)
+		colorOf := newColorer(cfg, sh.ncurve)
+		for frame := 0; frame < cfg.NFrames; frame++ {
+			for _, pt := range [][3]float64{{0, 0, 0}, {cfg.Cycles * 6.28, 1, 1}, {3, -1, 0.5}} {
+				idx := colorOf(frame, pt[0], pt[1], pt[2])
+				if idx == blackIndex || int(idx) > sh.ncurve {
+					t.Errorf("%s: cor %d fora de 1..%d", mode, idx, sh.ncurve)
+				}
+			}
+		}
+	}
+}
+
+// TestLongTrailGradient é a regressão de -render lines com rastro longo e gradiente:
+// com trail 15 ou 16 os tons de anti-aliasing passavam de 127, sobrava 1 tom de
+// gradiente e gradient dividia 0 por 0
+func TestLongTrailGradient(t *testing.T) {
+	for _, trail := range []int{15, 16} {
+		for _, mode := range []ColorMode{ColorByT, ColorByFrame, ColorByRadius} {
+			cfg := smallConfig()
+			cfg.Size = 10
+			cfg.NFrames = trail + 1
+			cfg.Freq = 1.5
+			cfg.Render = RenderLines
+			cfg.ColorMode = mode
+			cfg.Trail = trail
+			sh := newShading(cfg)
+			if sh.ncurve < 2 || len(sh.palette) > maxColors {
+				t.Errorf("trail %d, %s: %d cores da curva e paleta de %d, esperado >= 2 e <= %d",
+					trail, mode, sh.ncurve, len(sh.palette), maxColors)
+			}
+			if _, err := Animate(cfg); err != nil {
+				t.Errorf("trail %d, %s: %v", trail, mode, err)
+			}
+		}
+	}
+}
+
+// TestGradientSingleStep confere que um gradiente de 1 tom é a primeira parada
+func TestGradientSingleStep(t *testing.T) {
+	stops := []color.Color{color.RGBA{0x10, 0, 0, 0xff}, color.RGBA{0, 0, 0x10, 0xff}}
+	if g := gradient(stops, 1); len(g) != 1 || g[0] != stops[0] {
+		t.Errorf("gradient(stops, 1) = %v, esperado [%v]", g, stops[0])
+	}
+}
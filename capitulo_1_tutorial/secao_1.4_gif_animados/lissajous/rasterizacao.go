@@ -0,0 +1,234 @@
+// Rasterização: transforma os pontos da curva em pixels
+// No modo points cada amostra acende um pixel (como no livro); no modo lines amostras
+// consecutivas são ligadas por segmentos com anti-aliasing
+package lissajous
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// RenderMode define como as amostras da curva viram pixels
+type RenderMode int
+
+const (
+	RenderPoints RenderMode = iota // Um pixel por amostra, sem anti-aliasing (livro)
+	RenderLines                    // Segmentos ligando amostras consecutivas, com anti-aliasing
+)
+
+// Nomes aceitos em -render e no parâmetro render do servidor
+var renderModeNames = []string{"points", "lines"}
+
+// Tons por cor da curva no modo lines: quantos níveis de cobertura o anti-aliasing distingue
+const aaLevels = 8
+
+// Distância mínima (em pixels) entre vértices no modo lines; amostras mais próximas
+// que isso são puladas, já que o segmento seguinte cobre os mesmos pixels
+const minSegment = 0.5
+
+func (m RenderMode) String() string {
+	if m >= 0 && int(m) < len(renderModeNames) {
+		return renderModeNames[m]
+	}
+	return fmt.Sprintf("RenderMode(%d)", int(m))
+}
+
+// Set implementa flag.Value, permitindo usar RenderMode direto em flag.Var
+func (m *RenderMode) Set(s string) error {
+	for i, name := range renderModeNames {
+		if s == name {
+			*m = RenderMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("modo de desenho %q desconhecido (use %s)", s, strings.Join(renderModeNames, ", "))
+}
+
+// levels devolve quantos tons por cor o modo precisa na paleta (ver shading), sem contar o rastro
+func (m RenderMode) levels() int {
+	if m == RenderLines {
+		return aaLevels
+	}
+	return 1
+}
+
+// pixelsPerSample estima quantos pixels cada amostra visita com o traço de largura stroke
+// (ver Options.Work): no modo lines, a largura mais a borda suavizada e o arredondamento
+// de cada lado, ao quadrado
+func (m RenderMode) pixelsPerSample(stroke float64) float64 {
+	if m == RenderLines {
+		side := math.Max(stroke, 1) + 3
+		return side * side
+	}
+	return 1
+}
+
+// canvas guarda, para cada pixel, a cobertura (0 = fundo, 1 = cor cheia) e a cor da curva
+// Quando dois traços disputam o mesmo pixel, vence o de maior cobertura
+type canvas struct {
+	w, h      int
+	cov       []float32
+	col       []uint8
+	intensity float64 // Brilho do traço atual (0..1], multiplica a cobertura; < 1 no rastro
+}
+
+func newCanvas(w, h int) *canvas {
+	return &canvas{w: w, h: h, cov: make([]float32, w*h), col: make([]uint8, w*h), intensity: 1}
+}
+
+// plot acende o pixel (x, y) com cobertura a (escalada por intensity) e cor col
+// Pixels fora do canvas são ignorados
+// Com cobertura igual, o traço mais recente vence (como SetColorIndex no livro)
+func (c *canvas) plot(x, y int, a float64, col uint8) {
+	if x < 0 || y < 0 || x >= c.w || y >= c.h || a <= 0 {
+		return
+	}
+	a *= c.intensity
+	i := y*c.w + x
+	if v := float32(math.Min(a, 1)); v >= c.cov[i] {
+		c.cov[i] = v
+		c.col[i] = col
+	}
+}
+
+// paint copia o canvas para o frame paletizado, convertendo cobertura em tons da paleta
+// img pode ser um SubImage de uma imagem maior (as miniaturas de -sweep): o canvas é
+// copiado a partir de img.Rect.Min, linha a linha, respeitando o Stride
+func (c *canvas) paint(img *image.Paletted, sh shading) {
+	for y := 0; y < c.h; y++ {
+		row := img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y+y):]
+		for x, v := range c.cov[y*c.w : (y+1)*c.w] {
+			if v > 0 {
+				row[x] = sh.index(c.col[y*c.w+x], v)
+			}
+		}
+	}
+}
+
+// wuLine desenha um segmento de 1 pixel de largura com o algoritmo de Xiaolin Wu:
+// em cada coluna (ou linha, se o segmento for íngreme), a cobertura é dividida
+// entre os dois pixels mais próximos conforme a distância até cada um
+// intensity (0..1] escala a cobertura, simulando traços mais finos que 1 pixel
+func (c *canvas) wuLine(x0, y0, x1, y1, intensity float64, col uint8) {
+	steep := math.Abs(y1-y0) > math.Abs(x1-x0)
+	if steep {
+		x0, y0, x1, y1 = y0, x0, y1, x1
+	}
+	if x0 > x1 {
+		x0, y0, x1, y1 = x1, y1, x0, y0
+	}
+	plot := func(x, y int, a float64) {
+		if steep {
+			c.plot(y, x, a*intensity, col)
+		} else {
+			c.plot(x, y, a*intensity, col)
+		}
+	}
+	gradient := 1.0
+	if dx := x1 - x0; dx != 0 {
+		gradient = (y1 - y0) / dx
+	}
+
+	// Primeira extremidade: a cobertura é proporcional ao quanto o segmento ocupa a coluna
+	xend := math.Round(x0)
+	yend := y0 + gradient*(xend-x0)
+	xgap := 1 - fpart(x0+0.5)
+	xpx1, ypx := int(xend), int(math.Floor(yend))
+	plot(xpx1, ypx, (1-fpart(yend))*xgap)
+	plot(xpx1, ypx+1, fpart(yend)*xgap)
+	intery := yend + gradient
+
+	// Segunda extremidade
+	xend = math.Round(x1)
+	yend = y1 + gradient*(xend-x1)
+	xgap = fpart(x1 + 0.5)
+	xpx2, ypx := int(xend), int(math.Floor(yend))
+	plot(xpx2, ypx, (1-fpart(yend))*xgap)
+	plot(xpx2, ypx+1, fpart(yend)*xgap)
+
+	// Colunas do meio
+	for x := xpx1 + 1; x < xpx2; x++ {
+		y := int(math.Floor(intery))
+		plot(x, y, 1-fpart(intery))
+		plot(x, y+1, fpart(intery))
+		intery += gradient
+	}
+}
+
+// thickLine desenha um segmento de largura width com bordas suavizadas
+// A cobertura de cada pixel vem da distância do seu centro até o segmento:
+// 1 dentro do traço, caindo linearmente até 0 ao longo de um pixel na borda
+func (c *canvas) thickLine(x0, y0, x1, y1, width float64, col uint8) {
+	r := width / 2
+	minX := int(math.Floor(math.Min(x0, x1) - r - 1))
+	maxX := int(math.Ceil(math.Max(x0, x1) + r + 1))
+	minY := int(math.Floor(math.Min(y0, y1) - r - 1))
+	maxY := int(math.Ceil(math.Max(y0, y1) + r + 1))
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			d := segmentDistance(float64(x), float64(y), x0, y0, x1, y1)
+			c.plot(x, y, r+0.5-d, col)
+		}
+	}
+}
+
+// segmentDistance é a distância do ponto (px, py) ao segmento (x0, y0)-(x1, y1)
+func segmentDistance(px, py, x0, y0, x1, y1 float64) float64 {
+	dx, dy := x1-x0, y1-y0
+	// u é a posição da projeção do ponto sobre o segmento (0 = início, 1 = fim)
+	u := 0.0
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		u = math.Max(0, math.Min(1, ((px-x0)*dx+(py-y0)*dy)/l2))
+	}
+	return math.Hypot(px-(x0+u*dx), py-(y0+u*dy))
+}
+
+// fpart é a parte fracionária de x
+func fpart(x float64) float64 {
+	return x - math.Floor(x)
+}
+
+// pen liga as amostras da curva no modo lines
+// Amostras a menos de minSegment pixels do último vértice são acumuladas até a próxima
+type pen struct {
+	cv           *canvas
+	width        float64
+	x, y         float64 // Último vértice desenhado
+	lastX, lastY float64 // Última amostra recebida (pode não ter sido desenhada ainda)
+	lastCol      uint8
+	started      bool // Já recebeu a primeira amostra
+	pending      bool // Há amostras depois do último vértice desenhado
+	drawn        bool // Já desenhou algum segmento
+}
+
+// lineTo liga o último vértice ao ponto (x, y), em pixels
+func (p *pen) lineTo(x, y float64, col uint8) {
+	p.lastX, p.lastY, p.lastCol = x, y, col
+	if !p.started {
+		p.x, p.y, p.started = x, y, true
+		return
+	}
+	if math.Hypot(x-p.x, y-p.y) < minSegment {
+		p.pending = true
+		return
+	}
+	p.segment(x, y, col)
+}
+
+// finish desenha o trecho final que ficou pendente (ou um ponto, se houve só uma amostra)
+func (p *pen) finish() {
+	if p.pending || p.started && !p.drawn {
+		p.segment(p.lastX, p.lastY, p.lastCol)
+	}
+}
+
+func (p *pen) segment(x, y float64, col uint8) {
+	if p.width <= 1 {
+		p.cv.wuLine(p.x, p.y, x, y, p.width, col)
+	} else {
+		p.cv.thickLine(p.x, p.y, x, y, p.width, col)
+	}
+	p.x, p.y, p.pending, p.drawn = x, y, false, true
+}
@@ -0,0 +1,72 @@
+package lissajous
+
+import (
+	"math"
+	"testing"
+)
+
+// TestWuLineHorizontal confere que um segmento horizontal centrado nos pixels tem cobertura total
+func TestWuLineHorizontal(t *testing.T) {
+	cv := newCanvas(10, 10)
+	cv.wuLine(1, 5, 8, 5, 1, 1)
+	for x := 1; x <= 8; x++ {
+		if got := cv.cov[5*cv.w+x]; x > 1 && x < 8 && got != 1 {
+			t.Errorf("cobertura em (%d, 5) = %g, esperada 1", x, got)
+		}
+		if got := cv.cov[4*cv.w+x] + cv.cov[6*cv.w+x]; got != 0 {
+			t.Errorf("vizinhos de (%d, 5) acesos com cobertura %g", x, got)
+		}
+	}
+}
+
+// TestWuLineSplitsCoverage confere que um segmento entre duas linhas de pixels divide a cobertura
+func TestWuLineSplitsCoverage(t *testing.T) {
+	cv := newCanvas(10, 10)
+	cv.wuLine(1, 4.25, 8, 4.25, 1, 1)
+	above, below := cv.cov[4*cv.w+5], cv.cov[5*cv.w+5]
+	if math.Abs(float64(above)-0.75) > 1e-6 || math.Abs(float64(below)-0.25) > 1e-6 {
+		t.Errorf("coberturas = %g e %g, esperadas 0.75 e 0.25", above, below)
+	}
+}
+
+// TestThickLineWidth confere a largura do traço grosso e a suavização das bordas
+func TestThickLineWidth(t *testing.T) {
+	cv := newCanvas(20, 20)
+	cv.thickLine(2, 10, 17, 10, 4, 1)
+	// Largura 4: de y=9 a y=11 cobertura total, y=8 e y=12 meia cobertura na borda
+	for y, want := range map[int]float32{10: 1, 9: 1, 11: 1, 8: 0.5, 12: 0.5, 7: 0, 13: 0} {
+		if got := cv.cov[y*cv.w+10]; got != want {
+			t.Errorf("cobertura em (10, %d) = %g, esperada %g", y, got, want)
+		}
+	}
+}
+
+// TestPenDrawsSingleSample garante que uma curva de uma amostra só ainda aparece
+func TestPenDrawsSingleSample(t *testing.T) {
+	cv := newCanvas(5, 5)
+	p := pen{cv: cv, width: 1}
+	p.lineTo(2, 2, 1)
+	p.finish()
+	if cv.cov[2*cv.w+2] == 0 {
+		t.Error("a única amostra não foi desenhada")
+	}
+}
+
+// TestShadingIndex confere a conversão de cobertura em tons da paleta
+func TestShadingIndex(t *testing.T) {
+	cfg := DefaultOptions()
+	cfg.Render = RenderLines
+	sh := newShading(cfg)
+	if sh.levels != aaLevels || len(sh.palette) != 1+sh.ncurve*aaLevels {
+		t.Fatalf("paleta com %d cores e %d tons, esperados %d e %d", len(sh.palette), sh.levels, 1+sh.ncurve*aaLevels, aaLevels)
+	}
+	if got := sh.index(1, 0); got != blackIndex {
+		t.Errorf("cobertura 0 virou o índice %d, esperado o fundo", got)
+	}
+	if got, want := sh.index(2, 1), uint8(2*aaLevels); got != want {
+		t.Errorf("cor 2 cheia virou o índice %d, esperado %d", got, want)
+	}
+	if got, want := sh.index(1, 0.01), uint8(1); got != want {
+		t.Errorf("cobertura mínima da cor 1 virou o índice %d, esperado %d", got, want)
+	}
+}
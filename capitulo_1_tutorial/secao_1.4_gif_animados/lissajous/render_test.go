@@ -0,0 +1,164 @@
+package lissajous
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// renderGIF chama Render e decodifica o resultado, como faria um usuário da biblioteca
+func renderGIF(t *testing.T, opts Options) *gif.GIF {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Render(&buf, opts); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("o resultado não é um GIF válido: %v", err)
+	}
+	return anim
+}
+
+// TestRenderFrames confere quantidade, delay e tamanho dos frames gerados
+func TestRenderFrames(t *testing.T) {
+	opts := smallConfig()
+	opts.Freq = 1.5
+	opts.NFrames = 7
+	opts.Delay = 12
+	anim := renderGIF(t, opts)
+
+	if len(anim.Image) != opts.NFrames {
+		t.Fatalf("%d frames, esperado %d", len(anim.Image), opts.NFrames)
+	}
+	if len(anim.Delay) != opts.NFrames {
+		t.Fatalf("%d delays, esperado %d", len(anim.Delay), opts.NFrames)
+	}
+	want := image.Rect(0, 0, 2*opts.Size+1, 2*opts.Size+1)
+	for i, img := range anim.Image {
+		if anim.Delay[i] != opts.Delay {
+			t.Errorf("frame %d: delay %d, esperado %d", i, anim.Delay[i], opts.Delay)
+		}
+		if img.Bounds() != want {
+			t.Errorf("frame %d: limites %v, esperado %v", i, img.Bounds(), want)
+		}
+	}
+}
+
+// TestRenderPalette confere que os frames usam a paleta pedida: o fundo ocupa a borda,
+// a curva aparece em todo frame e nenhum pixel aponta para fora da paleta
+func TestRenderPalette(t *testing.T) {
+	opts := smallConfig()
+	opts.Freq = 2
+	opts.Palette = []color.Color{
+		color.RGBA{0x10, 0x20, 0x30, 0xff},
+		color.RGBA{0xff, 0x00, 0x00, 0xff},
+		color.RGBA{0x00, 0x00, 0xff, 0xff},
+	}
+	anim := renderGIF(t, opts)
+
+	for i, img := range anim.Image {
+		pal := img.Palette
+		if len(pal) < len(opts.Palette) {
+			t.Fatalf("frame %d: paleta com %d cores, esperado ao menos %d", i, len(pal), len(opts.Palette))
+		}
+		for j, c := range opts.Palette {
+			if !sameColor(pal[j], c) {
+				t.Errorf("frame %d: cor %d da paleta é %v, esperado %v", i, j, pal[j], c)
+			}
+		}
+		// No modo alternate cada frame desenha com uma única cor da curva, começando pela segunda
+		curveIndex := uint8(1 + (i+1)%(len(opts.Palette)-1))
+		if img.ColorIndexAt(0, 0) != 0 {
+			t.Errorf("frame %d: o canto deveria ter a cor de fundo", i)
+		}
+		drawn := 0
+		for _, idx := range img.Pix {
+			switch {
+			case int(idx) >= len(pal):
+				t.Fatalf("frame %d: índice %d fora da paleta de %d cores", i, idx, len(pal))
+			case idx == curveIndex:
+				drawn++
+			case idx != 0:
+				t.Fatalf("frame %d: índice %d inesperado (fundo 0, curva %d)", i, idx, curveIndex)
+			}
+		}
+		if drawn == 0 {
+			t.Errorf("frame %d: nenhum pixel da curva", i)
+		}
+	}
+}
+
+// sameColor compara duas cores pelos valores RGBA, independente do tipo concreto
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
+
+// TestRenderInvalid confere que Render valida as opções antes de escrever qualquer byte
+func TestRenderInvalid(t *testing.T) {
+	tests := map[string]func(*Options){
+		"size zero":     func(o *Options) { o.Size = 0 },
+		"sem frames":    func(o *Options) { o.NFrames = 0 },
+		"paleta curta":  func(o *Options) { o.Palette = o.Palette[:1] },
+		"curva inexist": func(o *Options) { o.Curve = "espiral" },
+		"pngseq":        func(o *Options) { o.Format = FormatPNGSeq },
+	}
+	for name, change := range tests {
+		opts := smallConfig()
+		change(&opts)
+		var buf bytes.Buffer
+		if err := Render(&buf, opts); err == nil {
+			t.Errorf("%s: esperado erro", name)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: %d bytes escritos apesar do erro", name, buf.Len())
+		}
+	}
+}
+
+// failingWriter recusa toda escrita
+type failingWriter struct{}
+
+var errWrite = errors.New("disco cheio")
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errWrite }
+
+// TestRenderWriteError confere que o erro do destino chega a quem chamou Render
+func TestRenderWriteError(t *testing.T) {
+	for _, f := range []Format{FormatGIF, FormatAPNG, FormatSVG} {
+		opts := smallConfig()
+		opts.Format = f
+		if err := Render(failingWriter{}, opts); !errors.Is(err, errWrite) {
+			t.Errorf("%v: erro %v, esperado %v", f, err, errWrite)
+		}
+	}
+}
+
+// TestRenderStats confere os bytes informados por RenderStats, com e sem Optimize
+func TestRenderStats(t *testing.T) {
+	for _, optimize := range []bool{false, true} {
+		opts := smallConfig()
+		opts.Freq = 1.5
+		opts.PhaseStep = 0 // Figura parada: a otimização sempre diminui o GIF
+		opts.Optimize = optimize
+		var buf bytes.Buffer
+		stats, err := RenderStats(&buf, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Bytes != int64(buf.Len()) {
+			t.Errorf("optimize %v: %d bytes informados, %d escritos", optimize, stats.Bytes, buf.Len())
+		}
+		switch {
+		case !optimize && stats.UnoptimizedBytes != 0:
+			t.Errorf("sem optimize: UnoptimizedBytes = %d, esperado 0", stats.UnoptimizedBytes)
+		case optimize && stats.UnoptimizedBytes <= stats.Bytes:
+			t.Errorf("com optimize: %d bytes, %d sem otimização; esperado menos", stats.Bytes, stats.UnoptimizedBytes)
+		}
+	}
+}
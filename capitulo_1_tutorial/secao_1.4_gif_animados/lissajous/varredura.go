@@ -0,0 +1,266 @@
+// Varredura de frequências: uma folha de contato com uma miniatura da curva para cada
+// combinação de razão de frequências (linhas) e defasagem inicial (colunas)
+package lissajous
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Máximo de miniaturas em uma folha (linhas * colunas)
+const maxSweepCells = 256
+
+// Ratio é uma razão de frequências x:y; Value é a freq do oscilador y (x tem frequência 1)
+type Ratio struct {
+	Label string  // Como aparece na legenda: "2:3" ou "1.5"
+	Value float64 // y/x: 2:3 vira 1.5
+}
+
+// RatioList são as linhas da folha; implementa flag.Value para -sweep
+type RatioList []Ratio
+
+func (l *RatioList) String() string {
+	if l == nil {
+		return ""
+	}
+	labels := make([]string, len(*l))
+	for i, r := range *l {
+		labels[i] = r.Label
+	}
+	return strings.Join(labels, ",")
+}
+
+func (l *RatioList) Set(s string) error {
+	ratios, err := ParseRatios(s)
+	*l = ratios
+	return err
+}
+
+// ParseRatios aceita um inteiro N, que gera todas as razões irredutíveis x:y com
+// 1 <= x <= y <= N, ou uma lista separada por vírgulas de razões "x:y" e frequências soltas
+// Ex.: "4" = 1:1, 3:4, 2:3, 1:2, 1:3, 1:4; "1:2,2:3,1.5" = três linhas
+func ParseRatios(s string) ([]Ratio, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > 32 {
+			return nil, fmt.Errorf("sweep %d: o maior termo das razões deve estar entre 1 e 32", n)
+		}
+		return integerRatios(n), nil
+	}
+	var ratios []Ratio
+	for _, spec := range strings.Split(s, ",") {
+		spec = strings.TrimSpace(spec)
+		terms := strings.Split(spec, ":")
+		if len(terms) > 2 {
+			return nil, fmt.Errorf("razão %q inválida: use x:y ou uma frequência", spec)
+		}
+		var v [2]float64
+		for i, term := range terms {
+			x, err := strconv.ParseFloat(term, 64)
+			if err != nil || !finite(x) || x < 0 || (len(terms) == 2 && x == 0) {
+				return nil, fmt.Errorf("razão %q inválida: %q não é um número positivo", spec, term)
+			}
+			v[i] = x
+		}
+		r := Ratio{Label: spec, Value: v[0]}
+		if len(terms) == 2 {
+			r.Value = v[1] / v[0]
+		}
+		ratios = append(ratios, r)
+	}
+	return ratios, nil
+}
+
+// integerRatios gera as razões irredutíveis x:y com 1 <= x <= y <= n, da menor para a maior
+func integerRatios(n int) []Ratio {
+	var ratios []Ratio
+	for y := 1; y <= n; y++ {
+		for x := 1; x <= y; x++ {
+			if gcd(x, y) == 1 {
+				ratios = append(ratios, Ratio{Label: fmt.Sprintf("%d:%d", x, y), Value: float64(y) / float64(x)})
+			}
+		}
+	}
+	sort.SliceStable(ratios, func(i, j int) bool { return ratios[i].Value < ratios[j].Value })
+	return ratios
+}
+
+// gcd é o máximo divisor comum de a e b (algoritmo de Euclides)
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// ParseOffsets lê as defasagens das colunas, em múltiplos de Pi: "0,0.25,0.5"
+func ParseOffsets(s string) ([]float64, error) {
+	var offsets []float64
+	for _, field := range strings.Split(s, ",") {
+		x, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || !finite(x) {
+			return nil, fmt.Errorf("defasagem %q inválida: use números separados por vírgula (múltiplos de Pi)", field)
+		}
+		offsets = append(offsets, x)
+	}
+	return offsets, nil
+}
+
+// sheetLayout é a geometria da folha de contato
+type sheetLayout struct {
+	rows, cols int
+	scale      int // Tamanho de cada pixel da fonte das legendas
+	cell       int // Lado da miniatura (2*size+1)
+	label      int // Altura da faixa da legenda, acima da miniatura
+	gap        int // Espaço entre miniaturas e nas bordas
+	w, h       int // Tamanho da folha
+}
+
+func newSheetLayout(cfg Options) sheetLayout {
+	l := sheetLayout{rows: len(cfg.Sweep), cols: len(cfg.Offsets), cell: 2*cfg.Size + 1}
+	// A legenda acompanha o tamanho das miniaturas: fonte 3x5 ampliada
+	l.scale = max(1, cfg.Size/50)
+	l.label = (glyphHeight + 2) * l.scale
+	l.gap = 4 * l.scale
+	l.w = l.cols*l.cell + (l.cols+1)*l.gap
+	l.h = l.rows*(l.cell+l.label) + (l.rows+1)*l.gap
+	return l
+}
+
+// origin é o canto superior esquerdo da miniatura (linha r, coluna c), já abaixo da legenda
+func (l sheetLayout) origin(r, c int) image.Point {
+	return image.Pt(l.gap+c*(l.cell+l.gap), l.gap+r*(l.cell+l.label+l.gap)+l.label)
+}
+
+// animateSweep gera a folha de contato: cada frame tem uma miniatura por combinação
+// de razão (linha) e defasagem (coluna); as miniaturas giram juntas com -phase
+func animateSweep(cfg Options) (*gif.GIF, error) {
+	layout := newSheetLayout(cfg)
+	sh := newShading(cfg)
+	colorOf := newColorer(cfg, sh.ncurve)
+	// Cor das legendas: uma entrada extra na paleta, se houver espaço
+	pal := sh.palette
+	labelIndex := sh.index(uint8(sh.ncurve), 1)
+	if len(pal) < maxColors {
+		pal = append(pal[:len(pal):len(pal)], labelColor(cfg.Palette))
+		labelIndex = uint8(len(pal) - 1)
+	}
+
+	// Uma curva e uma lista de fases para cada miniatura
+	cells := layout.rows * layout.cols
+	curves := make([]curve, cells)
+	phases := make([][]float64, cells)
+	labels := make([]string, cells)
+	base := framePhases(cfg)
+	for r, rt := range cfg.Sweep {
+		cellCfg := cfg
+		cellCfg.Freq = rt.Value
+		c, err := newCurve(cellCfg)
+		if err != nil {
+			return nil, err
+		}
+		for col, off := range cfg.Offsets {
+			k := r*layout.cols + col
+			curves[k] = c
+			phases[k] = make([]float64, len(base))
+			for i, p := range base {
+				phases[k][i] = p + off*math.Pi
+			}
+			labels[k] = fmt.Sprintf("%s %gπ", rt.Label, off)
+		}
+	}
+
+	anim := gif.GIF{LoopCount: 0}
+	anim.Image = make([]*image.Paletted, cfg.NFrames)
+	anim.Delay = make([]int, cfg.NFrames)
+	for i := range anim.Image {
+		anim.Image[i] = image.NewPaletted(image.Rect(0, 0, layout.w, layout.h), pal)
+		anim.Delay[i] = cfg.Delay
+	}
+	// Cada tarefa desenha uma miniatura de um frame; miniaturas não se sobrepõem,
+	// então os workers podem escrever na mesma folha ao mesmo tempo
+	tasks := cfg.NFrames * cells
+	parallel(workerCount(cfg, tasks), tasks, func(task int) {
+		i, k := task/cells, task%cells
+		img := anim.Image[i]
+		o := layout.origin(k/layout.cols, k%layout.cols)
+		cellImg := img.SubImage(image.Rect(o.X, o.Y, o.X+layout.cell, o.Y+layout.cell)).(*image.Paletted)
+		renderFrame(cfg, curves[k], colorOf, i, phases[k]).paint(cellImg, sh)
+		// A legenda é cortada na largura da miniatura, sem invadir a vizinha
+		labelImg := img.SubImage(image.Rect(o.X, o.Y-layout.label, o.X+layout.cell, o.Y)).(*image.Paletted)
+		drawText(labelImg, o.X+layout.scale, o.Y-layout.label+layout.scale, layout.scale, labels[k], labelIndex)
+	})
+	return &anim, nil
+}
+
+// labelColor escolhe, entre as cores da curva, a mais distante do fundo (a mais legível)
+func labelColor(pal []color.Color) color.Color {
+	bg := color.RGBAModel.Convert(pal[blackIndex]).(color.RGBA)
+	best, bestDist := pal[len(pal)-1], -1
+	for _, c := range pal[1:] {
+		rgba := color.RGBAModel.Convert(c).(color.RGBA)
+		dr, dg, db := int(rgba.R)-int(bg.R), int(rgba.G)-int(bg.G), int(rgba.B)-int(bg.B)
+		if d := dr*dr + dg*dg + db*db; d > bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// Fonte bitmap mínima para as legendas: cada caractere tem 3x5 pixels ('#' = aceso)
+const (
+	glyphWidth  = 3
+	glyphHeight = 5
+)
+
+var glyphs = map[rune][glyphHeight]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", ".##", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", ".#.", ".#."},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'.': {"...", "...", "...", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	'+': {"...", ".#.", "###", ".#.", "..."},
+	'e': {"...", "###", "###", "#..", "###"},
+	'π': {"...", "###", "#.#", "#.#", "#.#"},
+	' ': {"...", "...", "...", "...", "..."},
+	'?': {"###", "..#", ".##", "...", ".#."},
+}
+
+// drawText escreve text em img a partir de (x, y), com cada pixel da fonte ampliado scale vezes
+// Caracteres fora da fonte aparecem como '?'; o que passar da imagem é cortado
+func drawText(img *image.Paletted, x, y, scale int, text string, index uint8) {
+	for _, ch := range text {
+		g, ok := glyphs[ch]
+		if !ok {
+			g = glyphs['?']
+		}
+		for gy, row := range g {
+			for gx, bit := range row {
+				if bit != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						if p := image.Pt(x+gx*scale+dx, y+gy*scale+dy); p.In(img.Rect) {
+							img.Pix[img.PixOffset(p.X, p.Y)] = index
+						}
+					}
+				}
+			}
+		}
+		x += (glyphWidth + 1) * scale
+	}
+}
@@ -0,0 +1,107 @@
+package lissajous
+
+import (
+	"image"
+	"testing"
+)
+
+// TestParseRatios confere as duas formas de -sweep: N e a lista explícita
+func TestParseRatios(t *testing.T) {
+	got, err := ParseRatios("4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"1:1", "3:4", "2:3", "1:2", "1:3", "1:4"}
+	if len(got) != len(want) {
+		t.Fatalf("parseRatios(\"4\") = %v, esperado %v", got, want)
+	}
+	for i, r := range got {
+		if r.Label != want[i] {
+			t.Errorf("razão %d = %s, esperada %s", i, r.Label, want[i])
+		}
+	}
+
+	got, err = ParseRatios("2:3, 1.5,1:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := []float64{1.5, 1.5, 4}
+	for i, r := range got {
+		if r.Value != values[i] {
+			t.Errorf("%s vale %g, esperado %g", r.Label, r.Value, values[i])
+		}
+	}
+
+	for _, bad := range []string{"0", "33", "1:0", "1:2:3", "a:b", "", "1:-2"} {
+		if _, err := ParseRatios(bad); err == nil {
+			t.Errorf("parseRatios(%q) deveria falhar", bad)
+		}
+	}
+}
+
+// TestAnimateSweep confere o tamanho da folha, as legendas e que a miniatura da
+// razão 1:1 sem defasagem é idêntica à curva desenhada sozinha com freq=1
+func TestAnimateSweep(t *testing.T) {
+	cfg := smallConfig()
+	cfg.NFrames = 2
+	cfg.Sweep = RatioList{{"1:1", 1}, {"1:2", 2}}
+	cfg.Offsets = []float64{0, 0.5, 1}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	anim, err := animateSweep(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layout := newSheetLayout(cfg)
+	if len(anim.Image) != 2 {
+		t.Fatalf("%d frames, esperados 2", len(anim.Image))
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != layout.w || b.Dy() != layout.h {
+		t.Errorf("folha de %v, esperado %dx%d", b, layout.w, layout.h)
+	}
+
+	single := cfg
+	single.Sweep = nil
+	single.Freq = 1
+	plain, err := Animate(single)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labelIndex := uint8(len(anim.Image[0].Palette) - 1)
+	for i, img := range anim.Image {
+		o := layout.origin(0, 0)
+		for y := 0; y < layout.cell; y++ {
+			for x := 0; x < layout.cell; x++ {
+				got, want := img.ColorIndexAt(o.X+x, o.Y+y), plain.Image[i].ColorIndexAt(x, y)
+				if got != want {
+					t.Fatalf("frame %d, pixel (%d, %d): índice %d, esperado %d", i, x, y, got, want)
+				}
+			}
+		}
+		// Cada miniatura tem a sua legenda na faixa acima dela
+		for r := 0; r < layout.rows; r++ {
+			for c := 0; c < layout.cols; c++ {
+				o := layout.origin(r, c)
+				band := img.SubImage(image.Rect(o.X, o.Y-layout.label, o.X+layout.cell, o.Y)).(*image.Paletted)
+				if countIndex(band, labelIndex) == 0 {
+					t.Errorf("frame %d: miniatura (%d, %d) sem legenda", i, r, c)
+				}
+			}
+		}
+	}
+}
+
+// countIndex conta os pixels de img com o índice de paleta idx
+func countIndex(img *image.Paletted, idx uint8) int {
+	n := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.ColorIndexAt(x, y) == idx {
+				n++
+			}
+		}
+	}
+	return n
+}
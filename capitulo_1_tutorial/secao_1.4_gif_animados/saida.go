@@ -0,0 +1,95 @@
+// Gravação da animação: um arquivo (ou a saída padrão) no formato escolhido,
+// ou uma sequência numerada de PNGs
+package main
+
+import (
+	"bufio"                  // Escrita com buffer no arquivo de saída
+	"errors"                 // Criação de erros de validação
+	"fmt"                    // Nomes dos arquivos da sequência e relatório de -optimize
+	"gif_animados/lissajous" // Geração das animações
+	"image/png"              // Codificação de cada frame da sequência
+	"io"                     // Destino genérico da animação
+	"os"                     // Criação dos arquivos e saída padrão
+	"path/filepath"          // Extensão do padrão da sequência
+	"strings"                // Procura de "%" no padrão da sequência
+)
+
+// writeOutput gera a animação no formato de cfg e grava em cfg.output
+func writeOutput(cfg config) error {
+	// O formato vem de -format ou é deduzido da extensão de -o
+	opts := cfg.Options
+	opts.Format = lissajous.ResolveFormat(cfg.Format, cfg.output)
+	if opts.Format == lissajous.FormatPNGSeq {
+		return writePNGSequence(cfg)
+	}
+	// Com "-" a animação vai para a saída padrão (útil em pipes)
+	if cfg.output == "-" {
+		return render(os.Stdout, opts)
+	}
+	// Cria o arquivo de saída para salvar a animação
+	f, err := os.Create(cfg.output)
+	if err != nil {
+		return err
+	}
+	// bufio evita uma chamada ao sistema a cada pequena escrita do SVG
+	w := bufio.NewWriter(f)
+	if err := render(w, opts); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	// O erro de Close também importa: é nele que falhas de escrita podem aparecer
+	return f.Close()
+}
+
+// render grava a animação em w; com -optimize, informa no stderr quanto o GIF diminuiu
+func render(w io.Writer, opts lissajous.Options) error {
+	stats, err := lissajous.RenderStats(w, opts)
+	if err == nil && stats.UnoptimizedBytes > 0 {
+		saved := 100 * (1 - float64(stats.Bytes)/float64(stats.UnoptimizedBytes))
+		fmt.Fprintf(os.Stderr, "gif_animados: -optimize: %d -> %d bytes (%.1f%% menor)\n",
+			stats.UnoptimizedBytes, stats.Bytes, saved)
+	}
+	return err
+}
+
+// sequencePath devolve o nome do arquivo do frame i
+// Sem "%" no padrão, o número entra antes da extensão: saida.png -> saida_007.png
+func sequencePath(pattern string, i int) string {
+	if strings.Contains(pattern, "%") {
+		return fmt.Sprintf(pattern, i)
+	}
+	ext := filepath.Ext(pattern)
+	return fmt.Sprintf("%s_%03d%s", strings.TrimSuffix(pattern, ext), i, ext)
+}
+
+// writePNGSequence grava um PNG por frame, para uso em codificadores externos (ffmpeg etc.)
+func writePNGSequence(cfg config) error {
+	if cfg.output == "-" {
+		return errors.New("o formato pngseq precisa de um padrão de arquivo em -o (ex.: frame_%03d.png)")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	anim, err := lissajous.Animate(cfg.Options)
+	if err != nil {
+		return err
+	}
+	for i, img := range anim.Image {
+		f, err := os.Create(sequencePath(cfg.output, i))
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package main
+
+import "testing"
+
+// TestSequencePath confere a numeração dos arquivos da sequência de PNGs
+func TestSequencePath(t *testing.T) {
+	if got := sequencePath("f_%04d.png", 7); got != "f_0007.png" {
+		t.Errorf("com padrão: %q", got)
+	}
+	if got := sequencePath("dir/saida.png", 7); got != "dir/saida_007.png" {
+		t.Errorf("sem padrão: %q", got)
+	}
+}
@@ -0,0 +1,202 @@
+// Modo servidor (exercício 1.12 do livro): a animação é gerada a cada requisição
+// e enviada direto para o http.ResponseWriter, sem passar por arquivo
+package main
+
+import (
+	"fmt"
+	"gif_animados/lissajous"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Limites mais apertados que os da linha de comando: qualquer pessoa com acesso
+// ao servidor escolhe os parâmetros, e cada requisição aloca todos os frames na memória
+const (
+	webMaxCycles  = 100     // Máximo de oscilações por requisição
+	webMaxSize    = 500     // Canvas máximo de 1001x1001 pixels
+	webMaxFrames  = 200     // Máximo de frames por requisição
+	webMaxPixels  = 1 << 24 // Máximo de pixels somando todos os frames (~16 MB)
+	webMaxSamples = 1e6     // Máximo de pontos calculados por frame
+	webMaxTrail   = 16      // Máximo de frames no rastro (cada um é desenhado de novo)
+	webMaxStroke  = 10      // Largura máxima do traço (cada segmento varre stroke² pixels)
+	webMaxWork    = 3e8     // Máximo de trabalho estimado por Options.Work (alguns segundos de CPU)
+)
+
+// lissajousHandler responde em /lissajous com um GIF gerado a partir da query string
+// Ex.: /lissajous?cycles=3&size=200&nframes=32&delay=4&freq=1.5&palette=fogo&colormode=t
+// Parâmetros ausentes usam os valores do livro; sem freq, ela é sorteada com seed
+func lissajousHandler(w http.ResponseWriter, r *http.Request) {
+	cfg, err := configFromQuery(r.URL.Query())
+	if err != nil {
+		// Parâmetro inválido ou exagerado: 400 antes de gastar qualquer memória
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Cabeçalhos permitem repetir a mesma animação depois (?seed=...)
+	w.Header().Set("Content-Type", cfg.Format.MIME())
+	w.Header().Set("X-Lissajous-Seed", strconv.FormatInt(cfg.seed, 10))
+	w.Header().Set("X-Lissajous-Freq", strconv.FormatFloat(cfg.Freq, 'g', -1, 64))
+	if err := lissajous.Render(w, cfg.Options); err != nil {
+		// Os bytes já começaram a sair: só resta registrar o erro (ex.: cliente desconectou)
+		log.Printf("lissajous: %v", err)
+	}
+}
+
+// configFromQuery monta a configuração a partir da query string e confere os limites
+func configFromQuery(q url.Values) (config, error) {
+	cfg := defaultConfig()
+	cfg.seed = time.Now().UnixNano()
+	if err := queryFloat(q, "cycles", &cfg.Cycles); err != nil {
+		return cfg, err
+	}
+	if err := queryFloat(q, "res", &cfg.Res); err != nil {
+		return cfg, err
+	}
+	if err := queryInt(q, "size", &cfg.Size); err != nil {
+		return cfg, err
+	}
+	if err := queryInt(q, "nframes", &cfg.NFrames); err != nil {
+		return cfg, err
+	}
+	if err := queryInt(q, "delay", &cfg.Delay); err != nil {
+		return cfg, err
+	}
+	if err := queryFloat(q, "phase", &cfg.PhaseStep); err != nil {
+		return cfg, err
+	}
+	if err := queryFloat(q, "stroke", &cfg.Stroke); err != nil {
+		return cfg, err
+	}
+	if err := queryInt(q, "trail", &cfg.Trail); err != nil {
+		return cfg, err
+	}
+	if s := q.Get("optimize"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return cfg, fmt.Errorf("optimize: %q não é um booleano (use 1 ou 0)", s)
+		}
+		cfg.Optimize = v
+	}
+	if s := q.Get("format"); s != "" {
+		if err := cfg.Format.Set(s); err != nil {
+			return cfg, err
+		}
+		if cfg.Format.MIME() == "" {
+			return cfg, fmt.Errorf("format: %q não pode ser enviado pelo servidor (use gif, apng ou svg)", s)
+		}
+	}
+	if s := q.Get("render"); s != "" {
+		if err := cfg.Render.Set(s); err != nil {
+			return cfg, err
+		}
+	}
+	if err := queryFloat(q, "decay", &cfg.Decay); err != nil {
+		return cfg, err
+	}
+	if err := queryFloat(q, "zfreq", &cfg.ZFreq); err != nil {
+		return cfg, err
+	}
+	if err := queryFloat(q, "tilt", &cfg.Tilt); err != nil {
+		return cfg, err
+	}
+	if s := q.Get("curve"); s != "" {
+		cfg.Curve = s
+	}
+	for name, dst := range map[string]*lissajous.OscillatorList{"xosc": &cfg.XOsc, "yosc": &cfg.YOsc, "rotary": &cfg.Rotary} {
+		if s := q.Get(name); s != "" {
+			if err := dst.Set(s); err != nil {
+				return cfg, fmt.Errorf("%s: %v", name, err)
+			}
+		}
+	}
+	if s := q.Get("seed"); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return cfg, fmt.Errorf("seed: %q não é um inteiro", s)
+		}
+		cfg.seed = seed
+	}
+	// Com freq explícita a semente não é usada
+	cfg.Freq = randomFreq(cfg.seed)
+	if err := queryFloat(q, "freq", &cfg.Freq); err != nil {
+		return cfg, err
+	}
+	if s := q.Get("palette"); s != "" {
+		p, err := lissajous.ParsePalette(s)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Palette = p
+	}
+	if s := q.Get("colormode"); s != "" {
+		if err := cfg.ColorMode.Set(s); err != nil {
+			return cfg, err
+		}
+	}
+	// Sem format, a resposta é um GIF, como no livro
+	cfg.Format = lissajous.ResolveFormat(cfg.Format, "")
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+	return cfg, checkWebLimits(cfg)
+}
+
+// checkWebLimits rejeita combinações que esgotariam a memória ou a CPU do servidor
+// Além dos limites de cada parâmetro, o trabalho estimado da animação inteira tem um teto
+func checkWebLimits(c config) error {
+	switch {
+	case c.Cycles > webMaxCycles:
+		return fmt.Errorf("cycles deve ser no máximo %d no servidor, recebido %g", webMaxCycles, c.Cycles)
+	case c.Cycles*2*math.Pi/c.Res > webMaxSamples:
+		return fmt.Errorf("res %g é pequena demais para %g ciclos no servidor", c.Res, c.Cycles)
+	case c.Trail > webMaxTrail:
+		return fmt.Errorf("trail deve ser no máximo %d no servidor, recebido %d", webMaxTrail, c.Trail)
+	case c.Stroke > webMaxStroke:
+		return fmt.Errorf("stroke deve ser no máximo %d no servidor, recebido %g", webMaxStroke, c.Stroke)
+	case c.Size > webMaxSize:
+		return fmt.Errorf("size deve ser no máximo %d no servidor, recebido %d", webMaxSize, c.Size)
+	case c.NFrames > webMaxFrames:
+		return fmt.Errorf("nframes deve ser no máximo %d no servidor, recebido %d", webMaxFrames, c.NFrames)
+	case (2*c.Size+1)*(2*c.Size+1)*c.NFrames > webMaxPixels:
+		return fmt.Errorf("size %d com %d frames ultrapassa o limite de %d pixels do servidor", c.Size, c.NFrames, webMaxPixels)
+	case c.Work() > webMaxWork:
+		// Cada limite sozinho pode estar folgado e o produto deles, não: o rastro redesenha a
+		// curva inteira em cada frame, e o traço largo visita stroke² pixels por amostra
+		return fmt.Errorf("a animação pedida daria trabalho demais para o servidor (%.2g, o máximo é %.2g): "+
+			"reduza cycles/res, nframes, trail ou stroke", c.Work(), float64(webMaxWork))
+	}
+	return nil
+}
+
+// queryFloat lê o parâmetro name como float64, mantendo *dst se ele estiver ausente
+func queryFloat(q url.Values, name string, dst *float64) error {
+	s := q.Get(name)
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("%s: %q não é um número", name, s)
+	}
+	*dst = v
+	return nil
+}
+
+// queryInt lê o parâmetro name como int, mantendo *dst se ele estiver ausente
+func queryInt(q url.Values, name string, dst *int) error {
+	s := q.Get(name)
+	if s == "" {
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("%s: %q não é um inteiro", name, s)
+	}
+	*dst = v
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"image/gif"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestLissajousHandler confere que o GIF respeita a query string
+func TestLissajousHandler(t *testing.T) {
+	req := httptest.NewRequest("GET", "/lissajous?size=20&nframes=3&delay=5&freq=2&palette=ffffff,000000", nil)
+	rec := httptest.NewRecorder()
+	lissajousHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, esperado 200: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/gif" {
+		t.Errorf("Content-Type = %q, esperado image/gif", ct)
+	}
+	anim, err := gif.DecodeAll(rec.Body)
+	if err != nil {
+		t.Fatalf("resposta não é um GIF: %v", err)
+	}
+	if len(anim.Image) != 3 {
+		t.Errorf("%d frames, esperados 3", len(anim.Image))
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != 41 || b.Dy() != 41 {
+		t.Errorf("frame de %dx%d, esperado 41x41", b.Dx(), b.Dy())
+	}
+	if anim.Delay[0] != 5 {
+		t.Errorf("delay = %d, esperado 5", anim.Delay[0])
+	}
+}
+
+// TestLissajousHandlerRejects confere que parâmetros inválidos ou exagerados viram 400
+func TestLissajousHandlerRejects(t *testing.T) {
+	for _, query := range []string{
+		"size=abc",
+		"size=0",
+		"size=100000",
+		"nframes=1000",
+		"size=500&nframes=200",
+		"cycles=1e9",
+		"res=0",
+		"freq=-1",
+		"freq=NaN",
+		"palette=000000",
+		"palette=zzzzzz,ffffff",
+		"optimize=talvez",
+		"format=apng&optimize=1",
+		"trail=64",
+		"trail=17",
+		"format=svg&trail=2",
+		"render=lines&stroke=11",
+		"curve=harmonograph&xosc=1:1,2:1,3:1,4:1,5:1,6:1,7:1,8:1,9:1",
+	} {
+		req := httptest.NewRequest("GET", "/lissajous?"+query, nil)
+		rec := httptest.NewRecorder()
+		lissajousHandler(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, esperado 400", query, rec.Code)
+		}
+	}
+}
+
+// TestWebWorkBudget confere o teto de trabalho: cada parâmetro dentro do seu limite, mas
+// o produto deles (como o rastro longo com traço largo em 2 frames) é recusado
+func TestWebWorkBudget(t *testing.T) {
+	for _, query := range []string{
+		"render=lines&stroke=10&trail=16&cycles=100&res=0.00063&nframes=2",
+		"render=lines&trail=16&cycles=100&res=0.00063&nframes=200",
+		"cycles=100&res=0.00063&nframes=200",
+	} {
+		req := httptest.NewRequest("GET", "/lissajous?"+query, nil)
+		rec := httptest.NewRecorder()
+		lissajousHandler(rec, req)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "trabalho") {
+			t.Errorf("%s: status = %d (%s), esperado 400 pelo trabalho", query, rec.Code, rec.Body)
+		}
+	}
+	// O padrão do livro e um traço com rastro moderado continuam aceitos
+	for _, query := range []string{"", "render=lines&stroke=3&trail=4&nframes=32", "cycles=100&res=0.00063&nframes=50"} {
+		if _, err := configFromQuery(mustQuery(t, query)); err != nil {
+			t.Errorf("%q: %v", query, err)
+		}
+	}
+}
+
+// mustQuery interpreta uma query string de teste
+func mustQuery(t *testing.T, query string) url.Values {
+	t.Helper()
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
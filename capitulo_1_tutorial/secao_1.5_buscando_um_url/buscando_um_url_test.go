@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// capture troca *f (os.Stdout ou os.Stderr) por um arquivo temporário enquanto fn roda
+// e devolve o que foi escrito nele
+func capture(t *testing.T, f **os.File, fn func()) string {
+	t.Helper()
+	tmp, err := os.CreateTemp(t.TempDir(), "saida")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tmp.Close()
+	orig := *f
+	*f = tmp
+	defer func() { *f = orig }()
+	fn()
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// fetchQuiet chama fetch com o stderr capturado e devolve o resumo e as mensagens
+func fetchQuiet(t *testing.T, url string, opts options) (summary, string) {
+	t.Helper()
+	var s summary
+	stderr := capture(t, &os.Stderr, func() {
+		s = fetch(context.Background(), mustClient(t, opts), url, opts)
+	})
+	return s, stderr
+}
+
+// TestStatusExitCode confere a classe de cada status
+func TestStatusExitCode(t *testing.T) {
+	for status, want := range map[int]int{
+		200: 0, 204: 0, 304: 0, 399: 0,
+		400: exitClient, 404: exitClient, 499: exitClient,
+		500: exitServer, 503: exitServer, 599: exitServer,
+	} {
+		if got := statusExitCode(status); got != want {
+			t.Errorf("statusExitCode(%d) = %d, esperado %d", status, got, want)
+		}
+	}
+}
+
+// TestFetchExitCodes confere o bit de saída de cada tipo de falha, que é o que os scripts
+// leem do código de saída, e que com -fail o corpo da resposta de erro não é impresso
+func TestFetchExitCodes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/404":
+			http.Error(w, "sumiu", http.StatusNotFound)
+		case "/503":
+			http.Error(w, "fora do ar", http.StatusServiceUnavailable)
+		default:
+			io.WriteString(w, "ok")
+		}
+	}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close() // Conexão recusada
+
+	tests := []struct {
+		name   string
+		url    string
+		opts   options
+		want   int
+		stdout string
+	}{
+		{"200", srv.URL, options{}, 0, "ok"},
+		{"404 sem -fail", srv.URL + "/404", options{}, 0, "sumiu\n"},
+		{"404 com -fail", srv.URL + "/404", options{fail: true}, exitClient, ""},
+		{"503 com -fail", srv.URL + "/503", options{fail: true}, exitServer, ""},
+		{"conexão recusada", closed.URL, options{}, exitNetwork, ""},
+	}
+	for _, tt := range tests {
+		var code int
+		stdout := capture(t, &os.Stdout, func() {
+			capture(t, &os.Stderr, func() { code = fetch(context.Background(), mustClient(t, tt.opts), tt.url, tt.opts).Exit })
+		})
+		if code != tt.want || stdout != tt.stdout {
+			t.Errorf("%s: código %d e stdout %q, esperado %d e %q", tt.name, code, stdout, tt.want, tt.stdout)
+		}
+	}
+}
+
+// TestValidate confere as combinações de flags recusadas (código de saída 2)
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		set   func(*options)
+		nargs int
+		ok    bool
+	}{
+		{"padrão", func(o *options) {}, 1, true},
+		{"-o com uma URL", func(o *options) { o.output = "a.html" }, 1, true},
+		{"-o com duas URLs", func(o *options) { o.output = "a.html" }, 2, false},
+		{"-o com -urls", func(o *options) { o.output, o.urls = "a.html", "lista.txt" }, 1, false},
+		{"-o e -O", func(o *options) { o.output, o.remoteName = "a.html", true }, 1, false},
+		{"-retry negativo", func(o *options) { o.retry = -1 }, 1, false},
+		{"-timeout negativo", func(o *options) { o.timeout = -1 }, 1, false},
+		{"-max-redirs negativo", func(o *options) { o.maxRedirs = -1 }, 1, false},
+		{"-json com -o -", func(o *options) { o.json, o.output = true, "-" }, 1, false},
+		{"-i com -I", func(o *options) { o.head, o.headOnly = true, true }, 1, false},
+		{"-C sem arquivo", func(o *options) { o.resume = true }, 1, false},
+		{"-C com -O", func(o *options) { o.resume, o.remoteName = true, true }, 1, true},
+		{"-C com -cache", func(o *options) { o.resume, o.output, o.cache = true, "a", "c" }, 1, false},
+		{"-C com -d", func(o *options) { o.resume, o.output, o.body.set = true, "a", true }, 1, false},
+		{"-compressed com -raw", func(o *options) { o.compressed, o.raw = true, true }, 1, false},
+		{"-key sem -cert", func(o *options) { o.key = "k.pem" }, 1, false},
+		{"-insecure com -cacert", func(o *options) { o.insecure, o.caCert = true, "ca.pem" }, 1, false},
+		{"-d @- com -urls -", func(o *options) { o.urls, o.body = "-", requestBody{set: true, stdin: true} }, 0, false},
+		{"-u e -bearer", func(o *options) { o.user, o.bearer = "a:b", "t" }, 1, false},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		tt.set(&opts)
+		if err := opts.validate(tt.nargs); (err == nil) != tt.ok {
+			t.Errorf("%s: validate = %v, esperado ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
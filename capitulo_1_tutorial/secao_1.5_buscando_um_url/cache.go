@@ -0,0 +1,361 @@
+// Cache em disco (-cache dir): respostas 200 de GET ficam guardadas com seus headers; dentro
+// do prazo de Cache-Control max-age (ou Expires) são usadas sem nenhuma requisição e, depois,
+// revalidadas com If-None-Match/If-Modified-Since: um 304 reaproveita o corpo guardado
+package main
+
+import (
+	"context"       // Requisição sintética das respostas servidas do cache
+	"crypto/sha256" // Nome dos arquivos a partir da URL
+	"encoding/hex"  // Hash em texto
+	"encoding/json" // Metadados das entradas
+	"fmt"           // Mensagem do corpo perdido
+	"io"            // Cópia do corpo para o cache enquanto é lido
+	"maps"          // Comparação dos headers citados no Vary
+	"net/http"      // Headers e respostas
+	"os"            // Arquivos do cache
+	"path/filepath" // Caminhos dentro do diretório do cache
+	"strconv"       // max-age e Age
+	"strings"       // Diretivas do Cache-Control
+	"time"          // Idade e validade das entradas
+)
+
+// Resultados do cache, no stderr e no campo "cache" do resumo
+const (
+	cacheHit         = "hit"         // Dentro da validade: nenhuma requisição foi feita
+	cacheRevalidated = "revalidated" // O servidor respondeu 304: o corpo veio do cache
+	cacheMiss        = "miss"        // O corpo veio do servidor (e foi guardado, se possível)
+)
+
+// cacheEntry são os metadados de uma resposta guardada em <hash>.json; o corpo, exatamente
+// como veio do servidor (ainda comprimido, se for o caso), fica em <hash>.body
+type cacheEntry struct {
+	URL      string      `json:"url"`       // URL pedida
+	FinalURL string      `json:"final_url"` // URL que respondeu, depois dos redirecionamentos
+	Proto    string      `json:"proto"`
+	Header   http.Header `json:"header"`
+	Stored   time.Time   `json:"stored"` // Quando a resposta chegou (ou foi revalidada)
+	Size     int64       `json:"size"`   // Tamanho do corpo, para detectar um .body truncado
+	// Valores, na requisição que trouxe a resposta, dos headers citados no seu Vary: a
+	// chave secundária da entrada, que só serve a buscas que mandam os mesmos valores
+	Vary map[string]string `json:"vary,omitempty"`
+}
+
+// cacheFiles são os caminhos da entrada de uma URL
+type cacheFiles struct {
+	meta, body string
+}
+
+// cacheable informa se a requisição pode usar o cache: só GET sem corpo e sem -C, e sem
+// autenticação, para não gravar em disco respostas que exigem credenciais. Um -H com
+// Authorization ou Cookie também conta como credencial
+func (o options) cacheable() bool {
+	method := o.method
+	if method == "" && !o.headOnly && !o.body.set {
+		method = http.MethodGet
+	}
+	return o.cache != "" && method == http.MethodGet && !o.body.set && !o.resume &&
+		o.user == "" && o.bearer == "" && o.cert == "" &&
+		o.requestHeader("Authorization") == "" && o.requestHeader("Cookie") == ""
+}
+
+// requestHeader devolve o valor do header name na requisição que newRequest monta (vazio
+// se ela não o leva): o -compressed define o Accept-Encoding, e o último -H vence
+func (o options) requestHeader(name string) string {
+	name = http.CanonicalHeaderKey(name)
+	value := ""
+	if name == "Accept-Encoding" && o.compressed {
+		value = acceptEncoding
+	}
+	for _, h := range o.headers {
+		if http.CanonicalHeaderKey(h.name) == name {
+			value = h.value
+		}
+	}
+	return value
+}
+
+// varied devolve os valores, na requisição de opts, dos headers citados no Vary de h
+func varied(h http.Header, opts options) map[string]string {
+	var values map[string]string
+	for _, v := range h.Values("Vary") {
+		for _, name := range strings.Split(v, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			name = http.CanonicalHeaderKey(name)
+			values[name] = opts.requestHeader(name)
+		}
+	}
+	return values
+}
+
+// filesFor devolve os arquivos da entrada de url
+// O Accept-Encoding entra na chave: o corpo é guardado como veio, e um gzip guardado não
+// serve para uma busca sem -compressed. Os demais headers do Vary, que só se conhecem com
+// a resposta, são conferidos depois, pelo campo Vary da entrada
+func filesFor(url string, opts options) cacheFiles {
+	accept := opts.requestHeader("Accept-Encoding")
+	sum := sha256.Sum256([]byte(url + "\x00" + accept))
+	base := filepath.Join(opts.cache, hex.EncodeToString(sum[:]))
+	return cacheFiles{meta: base + ".json", body: base + ".body"}
+}
+
+// load lê a entrada; nil se não existe, está corrompida ou o corpo não tem o tamanho certo
+func (f cacheFiles) load() *cacheEntry {
+	data, err := os.ReadFile(f.meta)
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if json.Unmarshal(data, &e) != nil {
+		return nil
+	}
+	if info, err := os.Stat(f.body); err != nil || info.Size() != e.Size {
+		return nil
+	}
+	return &e
+}
+
+// save grava os metadados em um arquivo temporário e o renomeia: outra execução lendo o
+// cache ao mesmo tempo nunca vê um JSON pela metade
+func (f cacheFiles) save(e *cacheEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.meta), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.meta)
+}
+
+// response monta a resposta servida do cache, com o corpo guardado e a requisição req
+func (f cacheFiles) response(e *cacheEntry, req *http.Request) (*http.Response, error) {
+	body, err := os.Open(f.body)
+	if err != nil {
+		return nil, err
+	}
+	major, minor, ok := http.ParseHTTPVersion(e.Proto)
+	if !ok {
+		major, minor = 1, 1
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         e.Proto,
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Header:        e.Header.Clone(),
+		Body:          body,
+		ContentLength: e.Size,
+		Request:       req,
+	}, nil
+}
+
+// cachePolicy resume o Cache-Control (ou o Expires) de uma resposta
+type cachePolicy struct {
+	lifetime time.Duration // Validade a partir da geração da resposta (0 = revalidar sempre)
+	noStore  bool          // no-store: não pode ser guardada
+}
+
+// policy lê a validade de h: max-age tem prioridade sobre Expires; no-cache obriga a
+// revalidar sempre. Sem nenhum dos dois, a resposta só é reusada depois de revalidada
+func policy(h http.Header) cachePolicy {
+	var p cachePolicy
+	maxAge := -1
+	for _, d := range strings.Split(strings.Join(h.Values("Cache-Control"), ","), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			p.noStore = true
+		case "no-cache":
+			maxAge = 0
+		case "max-age":
+			if n, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && n >= 0 && maxAge != 0 {
+				maxAge = n
+			}
+		}
+	}
+	switch {
+	case maxAge >= 0:
+		p.lifetime = time.Duration(maxAge) * time.Second
+	case h.Get("Expires") != "":
+		// Um Expires inválido (muitas vezes "0" ou "-1") significa "já expirou"
+		exp, err1 := http.ParseTime(h.Get("Expires"))
+		date, err2 := http.ParseTime(h.Get("Date"))
+		if err1 == nil && err2 == nil && exp.After(date) {
+			p.lifetime = exp.Sub(date)
+		}
+	}
+	return p
+}
+
+// age devolve a idade da entrada em now: o Age informado pelo servidor (ou por um proxy)
+// quando a resposta chegou, mais o tempo desde então
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	var initial time.Duration
+	if n, err := strconv.Atoi(e.Header.Get("Age")); err == nil && n > 0 {
+		initial = time.Duration(n) * time.Second
+	}
+	return initial + now.Sub(e.Stored)
+}
+
+// cachedSend é o send com o cache de -cache: serve a resposta guardada quando ainda vale,
+// revalida a vencida e guarda as respostas novas enquanto o corpo é lido
+// s.Cache recebe o resultado; attempts é 0 quando nenhuma requisição foi feita
+func cachedSend(ctx context.Context, client *http.Client, url string, opts options, s *summary) (*http.Response, func(), int, error) {
+	if !opts.cacheable() {
+		return send(ctx, client, url, opts)
+	}
+	files := filesFor(url, opts)
+	entry := files.load()
+	// Uma resposta guardada para outros valores dos headers do Vary não serve a esta busca;
+	// a nova resposta toma o lugar dela
+	if entry != nil && !maps.Equal(entry.Vary, varied(entry.Header, opts)) {
+		opts.logf("%s: a entrada do cache é de outra variante (Vary: %s)\n", url, entry.Header.Get("Vary"))
+		entry = nil
+	}
+	if entry != nil {
+		age, life := entry.age(time.Now()), policy(entry.Header).lifetime
+		if age < life {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, entry.FinalURL, nil)
+			if err == nil {
+				if resp, err := files.response(entry, req); err == nil {
+					s.Cache = cacheHit
+					opts.logf("%s: cache hit (idade %s, validade %s), sem requisição\n",
+						url, age.Round(time.Second), life)
+					return resp, func() {}, 0, nil
+				}
+			}
+		}
+		// Vencida: a requisição leva os validadores, e o servidor responde 304 se não mudou
+		opts.headers = append(headerList(nil), opts.headers...)
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			opts.headers = append(opts.headers, header{"If-None-Match", etag})
+		}
+		if lm := entry.Header.Get("Last-Modified"); lm != "" {
+			opts.headers = append(opts.headers, header{"If-Modified-Since", lm})
+		}
+	}
+
+	resp, stop, attempts, err := send(ctx, client, url, opts)
+	if err != nil {
+		return resp, stop, attempts, err
+	}
+	s.Cache = cacheMiss
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		// Os headers do 304 atualizam os guardados (validade, Date, ETag...); os do corpo, não
+		for name, values := range resp.Header {
+			switch name {
+			case "Content-Length", "Content-Encoding", "Transfer-Encoding", "Content-Range":
+			default:
+				entry.Header[name] = values
+			}
+		}
+		entry.Stored = time.Now()
+		if err := files.save(entry); err != nil {
+			opts.logf("%s: não foi possível atualizar o cache: %v\n", url, err)
+		}
+		cached, err := files.response(entry, resp.Request)
+		resp.Body.Close()
+		if err != nil {
+			stop()
+			return nil, func() {}, attempts, fmt.Errorf("304 Not Modified, mas o corpo do cache sumiu: %v", err)
+		}
+		s.Cache = cacheRevalidated
+		opts.logf("%s: cache revalidado (%s), corpo do cache\n", url, resp.Status)
+		return cached, stop, attempts, nil
+	case resp.StatusCode == http.StatusOK:
+		p := policy(resp.Header)
+		worth := p.lifetime > 0 || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+		if p.noStore || resp.Header.Get("Vary") == "*" || !worth {
+			opts.logf("%s: cache miss (a resposta não pode ser guardada)\n", url)
+			break
+		}
+		if body, err := newStoreBody(resp, url, files); err == nil {
+			body.entry.Vary = varied(resp.Header, opts)
+			resp.Body = body
+			opts.logf("%s: cache miss\n", url)
+		} else {
+			opts.logf("%s: cache miss; não foi possível guardar a resposta: %v\n", url, err)
+		}
+	default:
+		opts.logf("%s: cache miss\n", url)
+	}
+	return resp, stop, attempts, nil
+}
+
+// storeBody copia o corpo para um arquivo temporário do cache enquanto ele é lido
+// Só quando a leitura chega ao fim sem erro a entrada é gravada: um download interrompido
+// ou abortado por -max-size não deixa um corpo truncado no cache
+type storeBody struct {
+	io.ReadCloser
+	tmp   *os.File
+	entry *cacheEntry
+	files cacheFiles
+	done  bool  // Chegou ao io.EOF
+	err   error // Falha ao gravar no cache (a busca continua; só a entrada é descartada)
+}
+
+func newStoreBody(resp *http.Response, url string, files cacheFiles) (*storeBody, error) {
+	if err := os.MkdirAll(filepath.Dir(files.body), 0o700); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(files.body), ".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &storeBody{
+		ReadCloser: resp.Body,
+		tmp:        tmp,
+		files:      files,
+		entry: &cacheEntry{
+			URL:      url,
+			FinalURL: resp.Request.URL.String(),
+			Proto:    resp.Proto,
+			Header:   resp.Header.Clone(),
+			Stored:   time.Now(),
+		},
+	}, nil
+}
+
+func (b *storeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.err == nil {
+		_, b.err = b.tmp.Write(p[:n])
+		b.entry.Size += int64(n)
+	}
+	if err == io.EOF {
+		b.done = true
+	}
+	return n, err
+}
+
+// Close fecha o corpo e grava a entrada, se o corpo foi lido inteiro
+func (b *storeBody) Close() error {
+	err := b.ReadCloser.Close()
+	cerr := b.tmp.Close()
+	if !b.done || b.err != nil || cerr != nil {
+		os.Remove(b.tmp.Name())
+		return err
+	}
+	// O corpo entra antes dos metadados: um .json sempre aponta para um .body completo
+	if os.Rename(b.tmp.Name(), b.files.body) != nil || b.files.save(b.entry) != nil {
+		os.Remove(b.tmp.Name())
+	}
+	return err
+}
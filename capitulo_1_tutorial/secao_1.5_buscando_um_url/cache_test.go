@@ -0,0 +1,269 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Last-Modified dos testes de -cache
+const cacheLastModified = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// cacheOptions devolve as opções de -cache dir -o arquivo, com o arquivo num diretório novo
+func cacheOptions(t *testing.T, dir string) options {
+	t.Helper()
+	opts := testOptions()
+	opts.cache = dir
+	opts.output = filepath.Join(t.TempDir(), "saida")
+	return opts
+}
+
+// fetchCached chama fetch com -cache e devolve o resumo e o corpo gravado em -o
+func fetchCached(t *testing.T, url string, opts options) (summary, string) {
+	t.Helper()
+	s, stderr := fetchQuiet(t, url, opts)
+	if s.Exit != 0 {
+		t.Fatalf("código %d (%s)", s.Exit, stderr)
+	}
+	data, err := os.ReadFile(opts.output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, string(data)
+}
+
+// cacheDirFiles lista os arquivos do diretório do cache (nenhum se ele nem foi criado)
+func cacheDirFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// TestCacheHit confere que, dentro do max-age, a resposta vem do cache sem requisição
+func TestCacheHit(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Cache-Control", "max-age=60")
+		io.WriteString(w, "guardado")
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	if s, body := fetchCached(t, srv.URL, cacheOptions(t, dir)); s.Cache != cacheMiss || body != "guardado" {
+		t.Errorf("primeira busca: cache %q, corpo %q; esperado miss e o corpo", s.Cache, body)
+	}
+	s, body := fetchCached(t, srv.URL, cacheOptions(t, dir))
+	if s.Cache != cacheHit || s.Attempts != 0 || hits != 1 || s.Status != 200 || body != "guardado" {
+		t.Errorf("segunda busca: cache %q, %d tentativas, %d requisições, status %d, corpo %q; esperado hit sem requisição",
+			s.Cache, s.Attempts, hits, s.Status, body)
+	}
+}
+
+// TestCacheRevalidate confere a revalidação da entrada vencida: a requisição leva
+// If-None-Match e If-Modified-Since, e o 304 reaproveita o corpo guardado
+func TestCacheRevalidate(t *testing.T) {
+	var inm, ims []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inm = append(inm, r.Header.Get("If-None-Match"))
+		ims = append(ims, r.Header.Get("If-Modified-Since"))
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", cacheLastModified)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		io.WriteString(w, "versão 1")
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	fetchCached(t, srv.URL, cacheOptions(t, dir))
+	s, body := fetchCached(t, srv.URL, cacheOptions(t, dir))
+	if s.Cache != cacheRevalidated || s.Attempts != 1 || s.Status != 200 || body != "versão 1" {
+		t.Errorf("cache %q, %d tentativas, status %d, corpo %q; esperado revalidated e o corpo guardado",
+			s.Cache, s.Attempts, s.Status, body)
+	}
+	if len(inm) != 2 || inm[0] != "" || ims[0] != "" || inm[1] != `"v1"` || ims[1] != cacheLastModified {
+		t.Errorf("If-None-Match %q e If-Modified-Since %q; esperados só na segunda requisição", inm, ims)
+	}
+}
+
+// TestCacheNotStored confere as respostas que não podem ir para o cache: nada é gravado
+// e a busca seguinte vai ao servidor
+func TestCacheNotStored(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+	}{
+		{"no-store", http.Header{"Cache-Control": {"max-age=60, no-store"}, "Etag": {`"v1"`}}},
+		{"Vary: *", http.Header{"Cache-Control": {"max-age=60"}, "Vary": {"*"}}},
+		{"sem validade nem validadores", http.Header{}},
+	}
+	for _, tt := range tests {
+		hits := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			for name, values := range tt.header {
+				w.Header()[name] = values
+			}
+			io.WriteString(w, "não guardar")
+		}))
+		dir := filepath.Join(t.TempDir(), "cache")
+		fetchCached(t, srv.URL, cacheOptions(t, dir))
+		if files := cacheDirFiles(t, dir); len(files) != 0 {
+			t.Errorf("%s: gravados %q no cache", tt.name, files)
+		}
+		if s, _ := fetchCached(t, srv.URL, cacheOptions(t, dir)); s.Cache != cacheMiss || hits != 2 {
+			t.Errorf("%s: cache %q com %d requisições; esperado miss com 2", tt.name, s.Cache, hits)
+		}
+		srv.Close()
+	}
+}
+
+// TestCacheCredentials confere que uma busca com Authorization ou Cookie em -H não usa o
+// cache: a resposta protegida não é gravada nem servida a uma busca sem credenciais
+func TestCacheCredentials(t *testing.T) {
+	for _, h := range []header{{"Authorization", "Bearer segredo"}, {"cookie", "sessao=1"}} {
+		hits := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits++
+			w.Header().Set("Cache-Control", "max-age=60")
+			if r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" {
+				io.WriteString(w, "secreto")
+				return
+			}
+			io.WriteString(w, "público")
+		}))
+		dir := filepath.Join(t.TempDir(), "cache")
+		opts := cacheOptions(t, dir)
+		opts.headers = headerList{h}
+		if s, body := fetchCached(t, srv.URL, opts); s.Cache != "" || body != "secreto" {
+			t.Errorf("-H %s: cache %q, corpo %q; esperado sem cache e o corpo protegido", h.name, s.Cache, body)
+		}
+		if files := cacheDirFiles(t, dir); len(files) != 0 {
+			t.Errorf("-H %s: gravados %q no cache", h.name, files)
+		}
+		if s, body := fetchCached(t, srv.URL, cacheOptions(t, dir)); s.Cache != cacheMiss || hits != 2 || body != "público" {
+			t.Errorf("-H %s, depois sem credenciais: cache %q, %d requisições, corpo %q; esperado miss com 2 e o corpo público",
+				h.name, s.Cache, hits, body)
+		}
+		srv.Close()
+	}
+}
+
+// TestCacheVary confere a chave secundária do Vary: a entrada guardada para um valor de
+// Accept-Language não é servida a uma busca com outro, que a substitui
+func TestCacheVary(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		io.WriteString(w, "idioma "+r.Header.Get("Accept-Language"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	fetchLang := func(lang string) (summary, string) {
+		opts := cacheOptions(t, dir)
+		if lang != "" {
+			opts.headers = headerList{{"accept-language", lang}}
+		}
+		return fetchCached(t, srv.URL, opts)
+	}
+
+	tests := []struct {
+		lang, cache string
+		hits        int
+	}{
+		{"pt", cacheMiss, 1},
+		{"pt", cacheHit, 1},
+		{"en", cacheMiss, 2},
+		{"", cacheMiss, 3},
+		{"", cacheHit, 3},
+	}
+	for i, tt := range tests {
+		s, body := fetchLang(tt.lang)
+		if s.Cache != tt.cache || hits != tt.hits || body != "idioma "+tt.lang {
+			t.Errorf("busca %d (%q): cache %q, %d requisições, corpo %q; esperado %s com %d e o corpo do idioma",
+				i+1, tt.lang, s.Cache, hits, body, tt.cache, tt.hits)
+		}
+	}
+}
+
+// TestCacheTruncated confere que um corpo interrompido (conexão que cai ou -max-size) não
+// substitui a entrada boa: ela continua servindo depois da falha
+func TestCacheTruncated(t *testing.T) {
+	mode := "v1"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0")
+		switch {
+		case mode == "v1" && r.Header.Get("If-None-Match") == `"v1"`:
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusNotModified)
+		case mode == "v1":
+			w.Header().Set("ETag", `"v1"`)
+			io.WriteString(w, "versão 1")
+		case mode == "cai":
+			// Versão nova, mas a conexão cai no meio do corpo
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("Content-Length", "100")
+			io.WriteString(w, "versão 2 pela metade")
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		case mode == "grande":
+			// Versão nova, sem Content-Length, maior que -max-size
+			w.Header().Set("ETag", `"v3"`)
+			for i := 0; i < 4; i++ {
+				io.WriteString(w, strings.Repeat("x", 512))
+				w.(http.Flusher).Flush()
+			}
+		}
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+	fetchCached(t, srv.URL, cacheOptions(t, dir))
+
+	for _, tt := range []struct {
+		mode string
+		want int
+	}{
+		{"cai", exitRead},
+		{"grande", exitTooLarge},
+	} {
+		mode = tt.mode
+		opts := cacheOptions(t, dir)
+		opts.maxSize = 1024
+		if s, stderr := fetchQuiet(t, srv.URL, opts); s.Exit != tt.want {
+			t.Errorf("%s: código %d, esperado %d (%s)", tt.mode, s.Exit, tt.want, stderr)
+		}
+		entry := filesFor(srv.URL, opts).load()
+		if entry == nil || entry.Header.Get("ETag") != `"v1"` || entry.Size != int64(len("versão 1")) {
+			t.Errorf("%s: entrada %+v, esperada a da versão 1", tt.mode, entry)
+		}
+		for _, name := range cacheDirFiles(t, dir) {
+			if strings.HasPrefix(name, ".tmp-") {
+				t.Errorf("%s: temporário %s ficou no cache", tt.mode, name)
+			}
+		}
+	}
+
+	// A entrada boa continua revalidando
+	mode = "v1"
+	if s, body := fetchCached(t, srv.URL, cacheOptions(t, dir)); s.Cache != cacheRevalidated || body != "versão 1" {
+		t.Errorf("depois das falhas: cache %q, corpo %q; esperado revalidated e a versão 1", s.Cache, body)
+	}
+}
@@ -0,0 +1,94 @@
+// Opções de conexão do transporte: proxy explícito (-proxy), CA própria (-cacert),
+// certificado de cliente para mTLS (-cert/-key) e, só para testes, -insecure
+package main
+
+import (
+	"crypto/tls"  // Configuração TLS do transporte
+	"crypto/x509" // Conjunto de CAs confiáveis
+	"errors"      // Erro de verificação de certificado
+	"fmt"         // Mensagens de erro
+	"net/http"    // Transporte e proxy
+	"net/url"     // URL do proxy
+	"os"          // Leitura dos arquivos PEM
+	"strings"     // Esquema do proxy
+)
+
+// insecureWarning é impresso no stderr com -insecure, mesmo com -q
+const insecureWarning = "buscando_um_url: ATENÇÃO: -insecure desliga a verificação dos certificados TLS; " +
+	"qualquer um no caminho pode se passar pelo servidor, ler e alterar as respostas. Use só para testes\n"
+
+// configureTransport aplica ao transporte as opções de proxy e de TLS
+// Sem -proxy, vale o proxy das variáveis de ambiente (HTTP_PROXY, HTTPS_PROXY, NO_PROXY),
+// herdado de http.DefaultTransport
+func configureTransport(t *http.Transport, opts options) error {
+	if opts.proxy != "" {
+		u, err := parseProxy(opts.proxy)
+		if err != nil {
+			return err
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if opts.caCert == "" && opts.cert == "" && !opts.insecure {
+		return nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: opts.insecure}
+	if opts.caCert != "" {
+		// A CA própria é somada às do sistema: os sites públicos continuam funcionando
+		data, err := os.ReadFile(opts.caCert)
+		if err != nil {
+			return fmt.Errorf("-cacert: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("-cacert: nenhum certificado PEM em %s", opts.caCert)
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.cert != "" {
+		// Sem -key, a chave privada é procurada no mesmo arquivo do certificado
+		key := opts.key
+		if key == "" {
+			key = opts.cert
+		}
+		pair, err := tls.LoadX509KeyPair(opts.cert, key)
+		if err != nil {
+			return fmt.Errorf("-cert/-key: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+	// Trocar o TLSClientConfig não desliga o HTTP/2: o transporte clonado mantém
+	// ForceAttemptHTTP2
+	t.TLSClientConfig = cfg
+	return nil
+}
+
+// parseProxy interpreta o valor de -proxy; sem esquema, o proxy é HTTP ("host:porta")
+func parseProxy(raw string) (*url.URL, error) {
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("-proxy %q inválido: %v", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("-proxy: esquema %q não suportado (use http, https ou socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("-proxy %q sem host", raw)
+	}
+	return u, nil
+}
+
+// isCertError informa se err é uma falha na verificação do certificado do servidor
+// Não adianta tentar de novo: o certificado vai continuar o mesmo
+func isCertError(err error) bool {
+	var verr *tls.CertificateVerificationError
+	return errors.As(err, &verr)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePEM grava blocos PEM em um arquivo do diretório temporário do teste
+func writePEM(t *testing.T, name string, blocks ...*pem.Block) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, b := range blocks {
+		if err := pem.Encode(f, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// certPEM devolve o certificado do servidor de teste em PEM, como um -cacert
+func certPEM(t *testing.T, srv *httptest.Server) string {
+	t.Helper()
+	return writePEM(t, "ca.pem", &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+}
+
+// fetchStatus busca url com opts e devolve o status (ou o erro)
+func fetchStatus(t *testing.T, url string, opts options) (int, error) {
+	t.Helper()
+	resp, stop, _, err := send(context.Background(), mustClient(t, opts), url, opts)
+	defer stop()
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// TestTLSVerify confere a CA desconhecida, -cacert e -insecure
+func TestTLSVerify(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	opts := testOptions()
+	opts.retry = 2
+	_, err := fetchStatus(t, srv.URL, opts)
+	if !isCertError(err) {
+		t.Errorf("sem -cacert: erro %v, esperado erro de certificado", err)
+	}
+
+	opts.caCert = certPEM(t, srv)
+	if status, err := fetchStatus(t, srv.URL, opts); err != nil || status != 200 {
+		t.Errorf("com -cacert: status %d, erro %v; esperado 200", status, err)
+	}
+
+	opts.caCert = ""
+	opts.insecure = true
+	if status, err := fetchStatus(t, srv.URL, opts); err != nil || status != 200 {
+		t.Errorf("com -insecure: status %d, erro %v; esperado 200", status, err)
+	}
+}
+
+// TestCertErrorNotRetried confere que um certificado inválido não gera novas tentativas
+func TestCertErrorNotRetried(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	opts := testOptions()
+	opts.retry = 3
+	_, _, attempts, err := send(context.Background(), mustClient(t, opts), srv.URL, opts)
+	if err == nil || attempts != 1 {
+		t.Errorf("%d tentativas, erro %v; esperado 1 tentativa com erro", attempts, err)
+	}
+}
+
+// TestClientCert confere o mTLS: o servidor exige um certificado assinado pela CA de teste
+func TestClientCert(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CA de teste"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, _ := x509.ParseCertificate(caDER)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "cliente"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+
+	opts := testOptions()
+	opts.caCert = certPEM(t, srv)
+	if _, err := fetchStatus(t, srv.URL, opts); err == nil {
+		t.Error("sem -cert: esperado erro do servidor que exige mTLS")
+	}
+
+	certBlock := &pem.Block{Type: "CERTIFICATE", Bytes: der}
+	keyBlock := &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}
+	// Certificado e chave em arquivos separados (-cert e -key) e juntos (só -cert)
+	for name, files := range map[string][2]string{
+		"-cert e -key": {writePEM(t, "cert.pem", certBlock), writePEM(t, "key.pem", keyBlock)},
+		"só -cert":     {writePEM(t, "junto.pem", certBlock, keyBlock), ""},
+	} {
+		opts.cert, opts.key = files[0], files[1]
+		if status, err := fetchStatus(t, srv.URL, opts); err != nil || status != 200 {
+			t.Errorf("%s: status %d, erro %v; esperado 200", name, status, err)
+		}
+	}
+}
+
+// TestProxy confere que -proxy recebe a requisição, com a URL completa do destino
+func TestProxy(t *testing.T) {
+	var got string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.String()
+		io.WriteString(w, "resposta do proxy")
+	}))
+	defer proxy.Close()
+
+	for _, p := range []string{proxy.URL, proxy.Listener.Addr().String()} {
+		got = ""
+		opts := testOptions()
+		opts.proxy = p
+		// .invalid nunca resolve: só o proxy poderia responder
+		status, err := fetchStatus(t, "http://destino.invalid/pagina", opts)
+		if err != nil || status != 200 || got != "http://destino.invalid/pagina" {
+			t.Errorf("-proxy %s: status %d, erro %v, o proxy recebeu %q", p, status, err, got)
+		}
+	}
+}
+
+// TestTransportOptionsInvalid confere as mensagens de arquivos e proxies inválidos
+func TestTransportOptionsInvalid(t *testing.T) {
+	empty := writePEM(t, "vazio.pem")
+	tests := []struct {
+		name string
+		set  func(*options)
+	}{
+		{"-cacert inexistente", func(o *options) { o.caCert = filepath.Join(t.TempDir(), "nada.pem") }},
+		{"-cacert sem certificados", func(o *options) { o.caCert = empty }},
+		{"-cert sem chave", func(o *options) { o.cert = empty }},
+		{"-proxy com esquema ftp", func(o *options) { o.proxy = "ftp://proxy:21" }},
+		{"-proxy sem host", func(o *options) { o.proxy = "http://" }},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		tt.set(&opts)
+		if _, err := newClient(opts); err == nil {
+			t.Errorf("%s: esperado erro", tt.name)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Decodificação do corpo: gzip e deflate só quando pedidos com -compressed, e a conversão
+// para UTF-8 das páginas em ISO-8859-1, Windows-1252 ou ISO-8859-15 impressas no stdout.
+// -raw mantém os bytes exatamente como vieram do servidor
+package main
+
+import (
+	"bufio"          // Espiar o cabeçalho zlib do deflate
+	"compress/flate" // deflate "cru", mandado por alguns servidores
+	"compress/gzip"  // Content-Encoding: gzip
+	"compress/zlib"  // Content-Encoding: deflate, como define o HTTP
+	"fmt"            // Mensagens de erro
+	"io"             // Leitores encadeados
+	"mime"           // Parâmetro charset do Content-Type
+	"net/http"       // Headers da resposta
+	"strings"        // Nomes de charset e de codificação
+	"unicode/utf8"   // Codificação das runas em UTF-8
+)
+
+// acceptEncoding é o header enviado com -compressed: só o que decodeBody sabe desfazer
+const acceptEncoding = "gzip, deflate"
+
+// decodeBody devolve o leitor do corpo de resp já decodificado conforme -compressed e -raw
+// toStdout diz se o corpo vai ser impresso: só nesse caso o charset é convertido, pois um
+// arquivo gravado deve continuar igual ao do servidor (o <meta charset> dele continua valendo)
+func decodeBody(resp *http.Response, toStdout bool, opts options) (io.Reader, error) {
+	if opts.raw {
+		return resp.Body, nil
+	}
+	body := io.Reader(resp.Body)
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if opts.compressed {
+		var err error
+		if body, err = decompress(body, encoding); err != nil {
+			return nil, err
+		}
+		encoding = ""
+	}
+	// Bytes ainda comprimidos não são texto: convertê-los estragaria o arquivo
+	if !toStdout || (encoding != "" && encoding != "identity") {
+		return body, nil
+	}
+	charset, table, ok := contentCharset(resp.Header.Get("Content-Type"))
+	if !ok {
+		opts.logf("%s: charset %q desconhecido; o corpo é impresso como veio\n", resp.Request.URL, charset)
+	}
+	if table == nil {
+		return body, nil
+	}
+	opts.logf("%s: convertendo de %s para UTF-8\n", resp.Request.URL, charset)
+	return &charsetReader{r: body, high: table}, nil
+}
+
+// decompress desfaz o Content-Encoding; identity (ou nenhum) devolve o próprio body
+func decompress(body io.Reader, encoding string) (io.Reader, error) {
+	switch encoding {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err == io.EOF {
+			// Respostas sem corpo (204, 304) podem anunciar gzip mesmo assim
+			return strings.NewReader(""), nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corpo gzip inválido: %v", err)
+		}
+		return zr, nil
+	case "deflate":
+		// O HTTP define deflate como o formato zlib (RFC 1950), mas há servidores que mandam
+		// o deflate cru (RFC 1951); os dois primeiros bytes dizem qual é
+		br := bufio.NewReader(body)
+		if head, err := br.Peek(2); err == nil && isZlibHeader(head[0], head[1]) {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("corpo deflate inválido: %v", err)
+			}
+			return zr, nil
+		}
+		return flate.NewReader(br), nil
+	}
+	return nil, fmt.Errorf("Content-Encoding %q não suportado; use -raw para gravar o corpo como veio", encoding)
+}
+
+// isZlibHeader confere o cabeçalho zlib: método 8 (deflate) e os dois bytes, lidos como
+// um número de 16 bits, múltiplos de 31
+func isZlibHeader(cmf, flg byte) bool {
+	return cmf&0x0F == 8 && (uint16(cmf)<<8|uint16(flg))%31 == 0
+}
+
+// contentCharset devolve o nome do charset do Content-Type e a tabela para convertê-lo
+// A tabela é nil quando não há o que converter: sem charset, UTF-8 ou um charset
+// desconhecido (ok = false)
+func contentCharset(contentType string) (name string, table *[128]rune, ok bool) {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil || params["charset"] == "" {
+		return "", nil, true
+	}
+	name = strings.ToLower(strings.Trim(params["charset"], `"' `))
+	switch name {
+	case "utf-8", "utf8":
+		return name, nil, true
+	case "iso-8859-1", "iso8859-1", "latin1", "l1", "cp819", "us-ascii", "ascii",
+		"windows-1252", "cp1252", "x-cp1252":
+		// Como nos navegadores (WHATWG Encoding), ISO-8859-1 e ASCII são lidos como
+		// Windows-1252: muitos sites declaram latin1 mas usam aspas curvas e o "€" do 1252
+		return strings.ToUpper(name), &windows1252, true
+	case "iso-8859-15", "iso8859-15", "latin9", "latin-9", "l9":
+		return strings.ToUpper(name), &iso885915, true
+	}
+	return name, nil, false
+}
+
+// charsetReader converte para UTF-8 um texto em um charset de um byte por caractere
+// Os bytes abaixo de 0x80 são ASCII; high traz o caractere de cada byte de 0x80 a 0xFF
+type charsetReader struct {
+	r    io.Reader
+	high *[128]rune
+	in   [4096]byte // Bytes lidos de r
+	buf  []byte     // in convertido para UTF-8 (até 3 bytes por byte lido)
+	off  int        // Quanto de buf já foi entregue
+	err  error      // Erro de r, entregue depois do que já foi convertido
+}
+
+func (c *charsetReader) Read(p []byte) (int, error) {
+	for c.off == len(c.buf) {
+		if c.err != nil {
+			return 0, c.err
+		}
+		var n int
+		n, c.err = c.r.Read(c.in[:])
+		c.buf, c.off = c.buf[:0], 0
+		for _, b := range c.in[:n] {
+			if b < utf8.RuneSelf {
+				c.buf = append(c.buf, b)
+			} else {
+				c.buf = utf8.AppendRune(c.buf, c.high[b-0x80])
+			}
+		}
+	}
+	n := copy(p, c.buf[c.off:])
+	c.off += n
+	return n, nil
+}
+
+// latin1Table devolve a tabela do ISO-8859-1 (cada byte é o caractere de mesmo código)
+// com as trocas de diff aplicadas
+func latin1Table(diff map[byte]rune) [128]rune {
+	var t [128]rune
+	for i := range t {
+		t[i] = rune(0x80 + i)
+	}
+	for b, r := range diff {
+		t[b-0x80] = r
+	}
+	return t
+}
+
+var (
+	// windows1252 troca os caracteres de controle 0x80–0x9F do ISO-8859-1 por pontuação e
+	// letras; os cinco códigos sem caractere (81, 8D, 8F, 90, 9D) ficam como no ISO-8859-1
+	windows1252 = latin1Table(map[byte]rune{
+		0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„', 0x85: '…', 0x86: '†', 0x87: '‡',
+		0x88: 'ˆ', 0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ', 0x8E: 'Ž',
+		0x91: '‘', 0x92: '’', 0x93: '“', 0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+		0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›', 0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+	})
+	// iso885915 é o ISO-8859-1 com o "€" e sete letras no lugar de símbolos pouco usados
+	iso885915 = latin1Table(map[byte]rune{
+		0xA4: '€', 0xA6: 'Š', 0xA8: 'š', 0xB4: 'Ž', 0xB8: 'ž', 0xBC: 'Œ', 0xBD: 'œ', 0xBE: 'Ÿ',
+	})
+)
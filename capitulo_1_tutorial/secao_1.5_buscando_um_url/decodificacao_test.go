@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// compressed comprime s com o escritor criado por newWriter
+func compressed(t *testing.T, s string, newWriter func(io.Writer) io.WriteCloser) string {
+	t.Helper()
+	var b bytes.Buffer
+	w := newWriter(&b)
+	io.WriteString(w, s)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// gzipped, zlibbed e deflated comprimem s no gzip, no deflate do HTTP (zlib) e no deflate cru
+func gzipped(t *testing.T, s string) string {
+	return compressed(t, s, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+}
+
+func zlibbed(t *testing.T, s string) string {
+	return compressed(t, s, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })
+}
+
+func deflated(t *testing.T, s string) string {
+	return compressed(t, s, func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	})
+}
+
+// TestDecompress confere cada Content-Encoding aceito por -compressed
+func TestDecompress(t *testing.T) {
+	const text = "o corpo da resposta, o corpo da resposta"
+	tests := []struct {
+		name     string
+		encoding string
+		body     string
+		ok       bool
+	}{
+		{"sem codificação", "", text, true},
+		{"identity", "identity", text, true},
+		{"gzip", "gzip", gzipped(t, text), true},
+		{"x-gzip", "x-gzip", gzipped(t, text), true},
+		{"deflate zlib", "deflate", zlibbed(t, text), true},
+		{"deflate cru", "deflate", deflated(t, text), true},
+		{"gzip inválido", "gzip", text, false},
+		{"br", "br", text, false},
+	}
+	for _, tt := range tests {
+		r, err := decompress(strings.NewReader(tt.body), tt.encoding)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: erro %v, esperado ok = %v", tt.name, err, tt.ok)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got, err := io.ReadAll(r); err != nil || string(got) != text {
+			t.Errorf("%s: %q, %v; esperado %q", tt.name, got, err, text)
+		}
+	}
+
+	// Uma resposta sem corpo pode anunciar gzip
+	r, err := decompress(strings.NewReader(""), "gzip")
+	if err != nil {
+		t.Fatalf("gzip vazio: %v", err)
+	}
+	if got, _ := io.ReadAll(r); len(got) != 0 {
+		t.Errorf("gzip vazio: %q", got)
+	}
+}
+
+// TestIsZlibHeader confere o reconhecimento do cabeçalho zlib no deflate
+func TestIsZlibHeader(t *testing.T) {
+	tests := []struct {
+		cmf, flg byte
+		want     bool
+	}{
+		{0x78, 0x01, true}, // Sem compressão
+		{0x78, 0x9C, true}, // Padrão
+		{0x78, 0xDA, true}, // Máxima
+		{0x08, 0x1D, true}, // Janela de 256 bytes
+		{0x78, 0x9D, false},
+		{0x79, 0x9C, false}, // Método 9
+		{0x00, 0x00, false},
+	}
+	for _, tt := range tests {
+		if got := isZlibHeader(tt.cmf, tt.flg); got != tt.want {
+			t.Errorf("isZlibHeader(%#x, %#x) = %v, esperado %v", tt.cmf, tt.flg, got, tt.want)
+		}
+	}
+	for _, level := range []int{zlib.NoCompression, zlib.BestSpeed, zlib.DefaultCompression, zlib.BestCompression} {
+		var b bytes.Buffer
+		w, _ := zlib.NewWriterLevel(&b, level)
+		w.Close()
+		if head := b.Bytes(); !isZlibHeader(head[0], head[1]) {
+			t.Errorf("nível %d: cabeçalho %#x %#x não reconhecido", level, head[0], head[1])
+		}
+	}
+}
+
+// TestContentCharset confere o nome e a tabela escolhidos para cada Content-Type
+func TestContentCharset(t *testing.T) {
+	tests := []struct {
+		contentType string
+		name        string
+		table       *[128]rune
+		ok          bool
+	}{
+		{"", "", nil, true},
+		{"text/html", "", nil, true},
+		{"text/html; charset=utf-8", "utf-8", nil, true},
+		{"text/html; charset=UTF8", "utf8", nil, true},
+		{"text/html; charset=ISO-8859-1", "ISO-8859-1", &windows1252, true},
+		{`text/html; charset="latin1"`, "LATIN1", &windows1252, true},
+		{"text/plain; charset=us-ascii", "US-ASCII", &windows1252, true},
+		{"text/html; charset=windows-1252", "WINDOWS-1252", &windows1252, true},
+		{"text/html; charset=iso-8859-15", "ISO-8859-15", &iso885915, true},
+		{"text/html; charset=latin9", "LATIN9", &iso885915, true},
+		{"text/html; charset=koi8-r", "koi8-r", nil, false},
+		{"text/html; charset=", "", nil, true},
+		{"text/html;;", "", nil, true}, // Content-Type ilegível: sem conversão
+	}
+	for _, tt := range tests {
+		name, table, ok := contentCharset(tt.contentType)
+		if name != tt.name || table != tt.table || ok != tt.ok {
+			t.Errorf("contentCharset(%q) = %q, %p, %v; esperado %q, %p, %v",
+				tt.contentType, name, table, ok, tt.name, tt.table, tt.ok)
+		}
+	}
+}
+
+// TestCharsetReader confere a conversão dos bytes altos com cada tabela
+func TestCharsetReader(t *testing.T) {
+	const in = "caf\xe9 \x80 \xa4 \x93ol\xe1\x94 \x81"
+	tests := []struct {
+		name  string
+		table *[128]rune
+		want  string
+	}{
+		{"windows-1252", &windows1252, "café € ¤ “olá” \u0081"},
+		{"iso-8859-15", &iso885915, "café \u0080 € \u0093olá\u0094 \u0081"},
+	}
+	for _, tt := range tests {
+		got, err := io.ReadAll(&charsetReader{r: strings.NewReader(in), high: tt.table})
+		if err != nil || string(got) != tt.want {
+			t.Errorf("%s: %q, %v; esperado %q", tt.name, got, err, tt.want)
+		}
+		// Leituras de um byte por vez não podem cortar os caracteres de vários bytes
+		r := &charsetReader{r: iotest.OneByteReader(strings.NewReader(in)), high: tt.table}
+		if err := iotest.TestReader(r, []byte(tt.want)); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+	// Acima de 0x9F o Windows-1252 é igual ao ISO-8859-1
+	for b := 0xA0; b <= 0xFF; b++ {
+		if windows1252[b-0x80] != rune(b) {
+			t.Errorf("windows1252[%#x] = %q, esperado igual ao ISO-8859-1", b, windows1252[b-0x80])
+		}
+	}
+}
+
+// TestDecodeBody confere quando o corpo é descomprimido e quando o charset é convertido
+func TestDecodeBody(t *testing.T) {
+	const latin1 = "ol\xe1, \x93mundo\x94"
+	tests := []struct {
+		name        string
+		contentType string
+		encoding    string
+		body        string
+		toStdout    bool
+		set         func(*options)
+		want        string
+	}{
+		{"UTF-8", "text/plain; charset=utf-8", "", "olá", true, nil, "olá"},
+		{"charset desconhecido", "text/plain; charset=koi8-r", "", "\xf0\xd2", true, nil, "\xf0\xd2"},
+		{"latin1 no stdout", "text/plain; charset=iso-8859-1", "", latin1, true, nil, "olá, “mundo”"},
+		{"latin1 em arquivo", "text/plain; charset=iso-8859-1", "", latin1, false, nil, latin1},
+		{"latin1 com -raw", "text/plain; charset=iso-8859-1", "", latin1, true,
+			func(o *options) { o.raw = true }, latin1},
+		{"gzip com -compressed", "text/plain; charset=iso-8859-1", "gzip", gzipped(t, latin1), true,
+			func(o *options) { o.compressed = true }, "olá, “mundo”"},
+		{"gzip sem -compressed", "text/plain; charset=iso-8859-1", "gzip", gzipped(t, latin1), true,
+			nil, gzipped(t, latin1)},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		if tt.set != nil {
+			tt.set(&opts)
+		}
+		resp := &http.Response{
+			Header:  http.Header{"Content-Type": {tt.contentType}},
+			Body:    io.NopCloser(strings.NewReader(tt.body)),
+			Request: &http.Request{URL: &url.URL{Scheme: "http", Host: "gopl.io"}},
+		}
+		if tt.encoding != "" {
+			resp.Header.Set("Content-Encoding", tt.encoding)
+		}
+		r, err := decodeBody(resp, tt.toStdout, opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got, err := io.ReadAll(r); err != nil || string(got) != tt.want {
+			t.Errorf("%s: %q, %v; esperado %q", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+module buscando_um_url
+
+go 1.21
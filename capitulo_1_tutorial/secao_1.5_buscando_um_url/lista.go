@@ -0,0 +1,76 @@
+// Listas de URLs (-urls): além dos argumentos, as URLs podem vir de um arquivo ou do stdin,
+// uma por linha, para alimentar o programa com centenas de URLs vindas de outras ferramentas
+package main
+
+import (
+	"bufio"   // Leitura linha a linha
+	"fmt"     // Origem de cada URL nas mensagens
+	"io"      // Arquivo ou stdin
+	"os"      // Abertura do arquivo e stdin
+	"strings" // Espaços, comentários e BOM
+)
+
+// maxLine é o maior tamanho de linha aceito na lista (o padrão do bufio.Scanner é 64 KB)
+const maxLine = 1 << 20
+
+// target é uma URL a buscar, ainda como foi escrita, e de onde ela veio
+type target struct {
+	arg   string
+	where string // "argumento 2" ou "urls.txt:12", para as mensagens
+	line  bool   // Veio de -urls (e não dos argumentos)
+}
+
+// eachTarget chama fn para cada URL dos argumentos e depois, com -urls, do arquivo list
+// ("-" = stdin), na ordem em que aparecem
+// A lista é lida aos poucos: a primeira URL é buscada sem esperar o fim do stdin, o que
+// permite encadear o programa com outras ferramentas. Linhas em branco e as que começam
+// com "#" são puladas
+func eachTarget(args []string, list string, fn func(target)) error {
+	for i, arg := range args {
+		fn(target{arg: arg, where: fmt.Sprintf("argumento %d", i+1)})
+	}
+	if list == "" {
+		return nil
+	}
+
+	var r io.Reader = os.Stdin
+	name := "stdin"
+	if list != "-" {
+		f, err := os.Open(list)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r, name = f, list
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, maxLine)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if n == 1 {
+			// Arquivos salvos pelo Bloco de Notas podem começar com o BOM do UTF-8
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fn(target{arg: line, where: fmt.Sprintf("%s:%d", name, n), line: true})
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// seenURLs guarda de onde veio cada URL já buscada, para pular as repetidas
+// As chaves são URLs já normalizadas: "gopl.io" e "http://gopl.io" são a mesma
+type seenURLs map[string]string
+
+// add registra url, vinda de where; se ela já tinha aparecido, devolve de onde e false
+func (s seenURLs) add(url, where string) (first string, ok bool) {
+	if first, dup := s[url]; dup {
+		return first, false
+	}
+	s[url] = where
+	return "", true
+}
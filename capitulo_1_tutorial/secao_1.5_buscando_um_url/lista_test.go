@@ -0,0 +1,123 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeList grava o conteúdo de uma lista de -urls num arquivo temporário
+func writeList(t *testing.T, content string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "urls.txt")
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// collect devolve os alvos entregues por eachTarget
+func collect(t *testing.T, args []string, list string) ([]target, error) {
+	t.Helper()
+	var got []target
+	err := eachTarget(args, list, func(t target) { got = append(got, t) })
+	return got, err
+}
+
+// TestEachTarget confere os argumentos seguidos das linhas da lista, com as linhas em
+// branco, os comentários e o BOM pulados e a posição de cada URL
+func TestEachTarget(t *testing.T) {
+	list := writeList(t, "\ufeffgopl.io\n"+
+		"# comentário\n"+
+		"\n"+
+		"   \t\n"+
+		"  https://go.dev  \r\n"+
+		"   # comentário recuado\n"+
+		"não é uma url\n"+
+		"gopl.io") // Sem \n no fim
+	got, err := collect(t, []string{"a.com", "b.com"}, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []target{
+		{"a.com", "argumento 1", false},
+		{"b.com", "argumento 2", false},
+		{"gopl.io", list + ":1", true},
+		{"https://go.dev", list + ":5", true},
+		{"não é uma url", list + ":7", true}, // As inválidas chegam a fn, que dá o erro
+		{"gopl.io", list + ":8", true},       // As repetidas também (ver seenURLs)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("alvos %+v, esperados %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("alvo %d: %+v, esperado %+v", i+1, got[i], want[i])
+		}
+	}
+}
+
+// TestEachTargetStdin confere a lista lida do stdin com -urls -
+func TestEachTargetStdin(t *testing.T) {
+	f, err := os.Open(writeList(t, "gopl.io\n# fim\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	orig := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = orig }()
+	got, err := collect(t, nil, "-")
+	if err != nil || len(got) != 1 || got[0] != (target{"gopl.io", "stdin:1", true}) {
+		t.Errorf("alvos %+v, %v; esperado gopl.io em stdin:1", got, err)
+	}
+}
+
+// TestEachTargetErrors confere as listas que não podem ser lidas: os argumentos e as
+// linhas anteriores ao erro já foram entregues
+func TestEachTargetErrors(t *testing.T) {
+	if got, err := collect(t, []string{"a.com"}, filepath.Join(t.TempDir(), "nada.txt")); err == nil || len(got) != 1 {
+		t.Errorf("lista inexistente: %d alvos, erro %v; esperado o argumento e um erro", len(got), err)
+	}
+
+	list := writeList(t, "gopl.io\n"+strings.Repeat("x", maxLine+1)+"\ngo.dev\n")
+	got, err := collect(t, nil, list)
+	if err == nil || !strings.Contains(err.Error(), list) {
+		t.Errorf("linha longa demais: erro %v, esperado um erro com o nome da lista", err)
+	}
+	if len(got) != 1 || got[0].arg != "gopl.io" {
+		t.Errorf("linha longa demais: alvos %+v, esperado só gopl.io", got)
+	}
+}
+
+// TestSeenURLs confere as repetidas depois da normalização, como no laço de main
+func TestSeenURLs(t *testing.T) {
+	list := writeList(t, "gopl.io\nhttp://gopl.io\nhttps://gopl.io\nhttp://\ngopl.io\n")
+	seen := make(seenURLs)
+	var fetched, dups, bad []string
+	err := eachTarget(nil, list, func(t target) {
+		url, err := normalizeURL(t.arg, false)
+		if err != nil {
+			bad = append(bad, t.where)
+			return
+		}
+		if first, ok := seen.add(url, t.where); !ok {
+			dups = append(dups, t.where+" repete "+first)
+			return
+		}
+		fetched = append(fetched, url)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"http://gopl.io", "https://gopl.io"}; strings.Join(fetched, " ") != strings.Join(want, " ") {
+		t.Errorf("buscadas %q, esperadas %q", fetched, want)
+	}
+	if want := []string{list + ":2 repete " + list + ":1", list + ":5 repete " + list + ":1"}; strings.Join(dups, "|") != strings.Join(want, "|") {
+		t.Errorf("repetidas %q, esperadas %q", dups, want)
+	}
+	if len(bad) != 1 || bad[0] != list+":4" {
+		t.Errorf("inválidas %q, esperada só a linha 4", bad)
+	}
+}
@@ -0,0 +1,219 @@
+// Normalização e validação das URLs recebidas na linha de comando
+// http.Get exige uma URL absoluta com esquema; aqui "gopl.io" vira "http://gopl.io",
+// hosts internacionais viram punycode e cada problema ganha uma mensagem clara
+package main
+
+import (
+	"errors"  // Criação das mensagens de validação
+	"fmt"     // Formatação das mensagens de validação
+	"net/url" // Análise da URL (esquema, host, porta, caminho)
+	"regexp"  // Esquema no início do argumento
+	"strconv" // Conversão da porta para número
+	"strings" // Espaços, rótulos do host e minúsculas
+)
+
+// schemePrefix reconhece um esquema no início da URL (RFC 3986: letra, depois letras,
+// dígitos, "+", "-" ou ".") seguido de "://"; um "://" mais adiante, como em
+// "gopl.io/?volta=https://x", faz parte da query e não é esquema
+var schemePrefix = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+
+// normalizeURL transforma um argumento da linha de comando em uma URL pronta para a requisição
+// Sem strict, corrige o que é seguro adivinhar: espaços nas pontas e esquema ausente (vira
+// http://). Com strict esses casos viram erro. O host internacional (IDN) é convertido para
+// punycode nos dois modos, pois a conversão não adivinha nada: é só outra grafia do mesmo nome
+func normalizeURL(arg string, strict bool) (string, error) {
+	s := arg
+	if trimmed := strings.TrimSpace(s); trimmed != s {
+		if strict {
+			return "", errors.New("espaços em branco antes ou depois da URL")
+		}
+		s = trimmed
+	}
+	if s == "" {
+		return "", errors.New("URL vazia")
+	}
+
+	// "http:/gopl.io" perdeu uma barra; completá-lo com http:// daria o host "http"
+	if scheme, rest, ok := strings.Cut(s, ":"); ok && (strings.EqualFold(scheme, "http") || strings.EqualFold(scheme, "https")) &&
+		strings.HasPrefix(rest, "/") && !strings.HasPrefix(rest, "//") {
+		return "", fmt.Errorf("o esquema %s: precisa de duas barras (use %s:/%s)", scheme, scheme, rest)
+	}
+	// Sem "esquema://" no início não há esquema: "gopl.io/doc" ou "localhost:8000" (que
+	// url.Parse leria como esquema "localhost"). "//gopl.io" é uma URL sem esquema, mas com
+	// as barras
+	if !schemePrefix.MatchString(s) {
+		host := strings.TrimPrefix(s, "//")
+		if strict {
+			return "", fmt.Errorf("falta o esquema (use http://%s ou https://%s)", host, host)
+		}
+		s = "http://" + host
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		// O *url.Error repete a URL inteira; a mensagem já é impressa junto com o argumento
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return "", fmt.Errorf("URL inválida: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("esquema %q não suportado (use http ou https)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return "", errors.New("falta o host")
+	}
+	if port := u.Port(); port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("porta %q inválida (use um número de 1 a 65535)", port)
+		}
+	}
+
+	// IPv6 ("[::1]") não tem rótulos a converter
+	host := u.Hostname()
+	if !strings.Contains(host, ":") {
+		ascii, err := hostToASCII(host)
+		if err != nil {
+			return "", fmt.Errorf("host %q inválido: %v", host, err)
+		}
+		host = ascii
+	} else {
+		host = "[" + host + "]"
+	}
+	if port := u.Port(); port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+	return u.String(), nil
+}
+
+// hostToASCII deixa o host em minúsculas e converte cada rótulo não-ASCII para punycode
+// ("café.com.br" vira "xn--caf-dma.com.br"), conferindo o tamanho e os caracteres dos rótulos
+// Não aplica a normalização Unicode (NFC) da IDNA completa, que exigiria golang.org/x/text
+func hostToASCII(host string) (string, error) {
+	labels := strings.Split(strings.ToLower(host), ".")
+	for i, label := range labels {
+		// Um ponto final ("gopl.io.") é válido: indica um nome absoluto no DNS
+		if label == "" {
+			if i == len(labels)-1 && i > 0 {
+				continue
+			}
+			return "", errors.New("rótulo vazio (dois pontos seguidos?)")
+		}
+		ascii := true
+		for _, r := range label {
+			switch {
+			case r >= 0x80:
+				ascii = false
+			case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			default:
+				return "", fmt.Errorf("caractere %q não permitido", r)
+			}
+		}
+		if !ascii {
+			label = "xn--" + punycode(label)
+		}
+		// O DNS limita cada rótulo a 63 bytes (RFC 1035)
+		if len(label) > 63 {
+			return "", fmt.Errorf("rótulo %q tem mais de 63 caracteres", label)
+		}
+		labels[i] = label
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// Parâmetros do Punycode definidos na RFC 3492
+const (
+	punyBase    = 36
+	punyTMin    = 1
+	punyTMax    = 26
+	punySkew    = 38
+	punyDamp    = 700
+	punyBias    = 72
+	punyInitial = 128 // Primeiro código fora do ASCII
+)
+
+// punycode codifica um rótulo Unicode (sem o prefixo "xn--") pelo algoritmo da RFC 3492:
+// os caracteres ASCII são copiados, e a posição e o código de cada um dos demais viram
+// inteiros de tamanho variável escritos em base 36
+func punycode(label string) string {
+	runes := []rune(label)
+	var out []byte
+	for _, r := range runes {
+		if r < punyInitial {
+			out = append(out, byte(r))
+		}
+	}
+	basic := len(out)
+	if basic > 0 {
+		out = append(out, '-')
+	}
+
+	n, delta, bias := rune(punyInitial), 0, punyBias
+	for h := basic; h < len(runes); {
+		// O próximo código a codificar é o menor que ainda não foi tratado
+		m := rune(0x10FFFF)
+		for _, r := range runes {
+			if r >= n && r < m {
+				m = r
+			}
+		}
+		delta += int(m-n) * (h + 1)
+		n = m
+		for _, r := range runes {
+			if r < n {
+				delta++
+			}
+			if r != n {
+				continue
+			}
+			// Escreve delta como inteiro de tamanho variável, com limiares que dependem de bias
+			q := delta
+			for k := punyBase; ; k += punyBase {
+				t := k - bias
+				if t < punyTMin {
+					t = punyTMin
+				} else if t > punyTMax {
+					t = punyTMax
+				}
+				if q < t {
+					break
+				}
+				out = append(out, punyDigit(t+(q-t)%(punyBase-t)))
+				q = (q - t) / (punyBase - t)
+			}
+			out = append(out, punyDigit(q))
+			bias = punyAdapt(delta, h+1, h == basic)
+			delta = 0
+			h++
+		}
+		delta++
+		n++
+	}
+	return string(out)
+}
+
+// punyAdapt recalcula bias depois de cada código, como na seção 6.1 da RFC 3492
+func punyAdapt(delta, points int, first bool) int {
+	if first {
+		delta /= punyDamp
+	} else {
+		delta /= 2
+	}
+	delta += delta / points
+	k := 0
+	for delta > (punyBase-punyTMin)*punyTMax/2 {
+		delta /= punyBase - punyTMin
+		k += punyBase
+	}
+	return k + (punyBase-punyTMin+1)*delta/(delta+punySkew)
+}
+
+// punyDigit converte um dígito de 0 a 35 para a-z (0-25) ou 0-9 (26-35)
+func punyDigit(d int) byte {
+	if d < 26 {
+		return byte('a' + d)
+	}
+	return byte('0' + d - 26)
+}
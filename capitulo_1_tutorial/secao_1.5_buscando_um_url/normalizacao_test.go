@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPunycode usa as amostras da seção 7.1 da RFC 3492 e rótulos conhecidos
+func TestPunycode(t *testing.T) {
+	tests := []struct{ label, want string }{
+		// (A) Árabe (egípcio)
+		{"\u0644\u064A\u0647\u0645\u0627\u0628\u062A\u0643\u0644\u0645\u0648\u0634\u0639\u0631\u0628\u064A\u061F", "egbpdaj6bu4bxfgehfvwxn"},
+		// (B) Chinês (simplificado)
+		{"\u4ED6\u4EEC\u4E3A\u4EC0\u4E48\u4E0D\u8BF4\u4E2D\u6587", "ihqwcrb4cv8a8dqg056pqjye"},
+		// (E) Hebraico
+		{"\u05DC\u05DE\u05D4\u05D4\u05DD\u05E4\u05E9\u05D5\u05D8\u05DC\u05D0\u05DE\u05D3\u05D1\u05E8\u05D9\u05DD\u05E2\u05D1\u05E8\u05D9\u05EA", "4dbcagdahymbxekheh6e0a7fei0b"},
+		// (I) Russo (cirílico), já em minúsculas como chega de hostToASCII
+		{"\u043F\u043E\u0447\u0435\u043C\u0443\u0436\u0435\u043E\u043D\u0438\u043D\u0435\u0433\u043E\u0432\u043E\u0440\u044F\u0442\u043F\u043E\u0440\u0443\u0441\u0441\u043A\u0438", "b1abfaaepdrnnbgefbadotcwatmq2g4l"},
+		// (L) Japonês misturado com ASCII: "3<nen>B<gumi><kinpachi><sensei>"
+		{"3\u5E74B\u7D44\u91D1\u516B\u5148\u751F", "3B-ww4c5e180e575a65lsy2b"},
+		// (R) Japonês com letras ASCII no meio
+		{"\u305D\u306E\u30B9\u30D4\u30FC\u30C9\u3067", "d9juau41awczczp"},
+		// (S) Só ASCII: copiado e terminado pelo delimitador
+		{"-> $1.00 <-", "-> $1.00 <--"},
+		{"café", "caf-dma"},
+		{"bücher", "bcher-kva"},
+		{"münchen", "mnchen-3ya"},
+	}
+	for _, tt := range tests {
+		if got := punycode(tt.label); got != tt.want {
+			t.Errorf("punycode(%q) = %q, esperado %q", tt.label, got, tt.want)
+		}
+	}
+}
+
+// TestHostToASCII confere hosts internacionais, minúsculas e rótulos inválidos
+func TestHostToASCII(t *testing.T) {
+	tests := []struct{ host, want string }{
+		{"gopl.io", "gopl.io"},
+		{"GoPL.IO", "gopl.io"},
+		{"gopl.io.", "gopl.io."},
+		{"café.com.br", "xn--caf-dma.com.br"},
+		{"Bücher.example", "xn--bcher-kva.example"},
+		{"пример.рф", "xn--e1afmkfd.xn--p1ai"},
+		{"例え.テスト", "xn--r8jz45g.xn--zckzah"},
+	}
+	for _, tt := range tests {
+		if got, err := hostToASCII(tt.host); err != nil || got != tt.want {
+			t.Errorf("hostToASCII(%q) = %q, %v; esperado %q", tt.host, got, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"a..b", ".gopl.io", "go pl.io", "gopl!.io", strings.Repeat("a", 64) + ".io"} {
+		if got, err := hostToASCII(bad); err == nil {
+			t.Errorf("hostToASCII(%q) = %q, esperado erro", bad, got)
+		}
+	}
+}
+
+// TestNormalizeURL confere as correções sem -strict e as recusas com -strict
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		arg    string
+		strict bool
+		want   string // Vazio: esperado erro
+	}{
+		{"http://gopl.io", false, "http://gopl.io"},
+		{"gopl.io", false, "http://gopl.io"},
+		{"  gopl.io/doc ", false, "http://gopl.io/doc"},
+		{"//gopl.io", false, "http://gopl.io"},
+		{"localhost:8000", false, "http://localhost:8000"},
+		{"example.com/?next=https://foo", false, "http://example.com/?next=https://foo"},
+		{"example.com/?next=https://foo", true, ""},
+		{"https://café.com.br/menu", true, "https://xn--caf-dma.com.br/menu"},
+		{"http://[::1]:8080/", true, "http://[::1]:8080/"},
+		{"HTTP://GoPL.io", true, "http://gopl.io"},
+		{"gopl.io", true, ""},
+		{" http://gopl.io", true, ""},
+		{"", false, ""},
+		{"ftp://gopl.io", false, ""},
+		{"http://", false, ""},
+		{"http://gopl.io:0", false, ""},
+		{"http://gopl.io:99999", false, ""},
+		{"http://go pl.io", false, ""},
+		{"http:/gopl.io", false, ""},
+		{"https:/gopl.io", true, ""},
+	}
+	for _, tt := range tests {
+		got, err := normalizeURL(tt.arg, tt.strict)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("normalizeURL(%q, %v) = %q, esperado erro", tt.arg, tt.strict, got)
+		case tt.want != "" && (err != nil || got != tt.want):
+			t.Errorf("normalizeURL(%q, %v) = %q, %v; esperado %q", tt.arg, tt.strict, got, err, tt.want)
+		}
+	}
+}
+
+// TestNormalizeURLSuggestions confere as URLs sugeridas nas mensagens de erro
+func TestNormalizeURLSuggestions(t *testing.T) {
+	tests := []struct {
+		arg    string
+		strict bool
+		want   string
+	}{
+		{"gopl.io", true, "use http://gopl.io ou https://gopl.io"},
+		{"//gopl.io", true, "use http://gopl.io ou https://gopl.io"},
+		{"http:/gopl.io", false, "use http://gopl.io"},
+		{"https:/gopl.io/doc", true, "use https://gopl.io/doc"},
+	}
+	for _, tt := range tests {
+		_, err := normalizeURL(tt.arg, tt.strict)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("normalizeURL(%q, %v): erro %v, esperado com %q", tt.arg, tt.strict, err, tt.want)
+		}
+	}
+}
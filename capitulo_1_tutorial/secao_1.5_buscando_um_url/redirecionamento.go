@@ -0,0 +1,67 @@
+// Política de redirecionamentos: quantos seguir (-max-redirs), se um HTTPS pode mandar para
+// HTTP (-no-downgrade) e a cadeia percorrida até a resposta final (-redirects e -json)
+package main
+
+import (
+	"errors"   // Erro de política de redirecionamento
+	"fmt"      // Mensagens da política e da cadeia
+	"net/http" // Requisições e respostas da cadeia
+	"os"       // Cadeia impressa no stderr
+)
+
+// errRedirectPolicy é a causa das falhas de política; o cliente a devolve dentro de um *url.Error
+var errRedirectPolicy = errors.New("redirecionamento recusado")
+
+// checkRedirect é o CheckRedirect do cliente: decide se req, pedido pela resposta 3xx da
+// última requisição de via, deve ser seguido
+// Com -max-redirs 0, http.ErrUseLastResponse faz o cliente devolver a própria resposta 3xx
+func checkRedirect(req *http.Request, via []*http.Request, opts options) error {
+	if opts.maxRedirs == 0 {
+		return http.ErrUseLastResponse
+	}
+	// via tem todas as requisições já feitas: a primeira e uma por redirecionamento seguido
+	if len(via) > opts.maxRedirs {
+		return fmt.Errorf("%w: mais de %d redirecionamentos (-max-redirs)", errRedirectPolicy, opts.maxRedirs)
+	}
+	prev := via[len(via)-1]
+	if opts.noDowngrade && prev.URL.Scheme == "https" && req.URL.Scheme == "http" {
+		return fmt.Errorf("%w: %s manda para %s, saindo do HTTPS (-no-downgrade)", errRedirectPolicy, prev.URL, req.URL)
+	}
+	return nil
+}
+
+// hop é um passo da cadeia de redirecionamentos
+type hop struct {
+	URL      string `json:"url"`      // URL que respondeu com o redirecionamento
+	Status   int    `json:"status"`   // Código 3xx da resposta
+	Location string `json:"location"` // URL seguinte (já resolvida a partir do Location)
+}
+
+// redirectChain reconstrói a cadeia percorrida até resp, na ordem em que foi seguida
+// Não precisa guardar nada durante a busca: cada requisição feita por causa de um
+// redirecionamento guarda em Response a resposta 3xx que a originou
+func redirectChain(resp *http.Response) []hop {
+	var chain []hop
+	for r := resp.Request; r.Response != nil; r = r.Response.Request {
+		chain = append(chain, hop{
+			URL:      r.Response.Request.URL.String(),
+			Status:   r.Response.StatusCode,
+			Location: r.URL.String(),
+		})
+	}
+	// A volta foi do fim para o começo
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// printChain escreve no stderr um passo da cadeia por linha:
+//
+//	redirecionamento 1: 301 Moved Permanently http://gopl.io -> https://www.gopl.io/
+func printChain(chain []hop) {
+	for i, h := range chain {
+		fmt.Fprintf(os.Stderr, "redirecionamento %d: %d %s %s -> %s\n",
+			i+1, h.Status, http.StatusText(h.Status), h.URL, h.Location)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// chainServer responde /r/N com um 302 para /r/N-1, até /r/0, que responde "fim"
+func chainServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/r/"))
+		if err != nil || n <= 0 {
+			io.WriteString(w, "fim")
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/r/%d", n-1), http.StatusFound)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestMaxRedirs confere o limite de -max-redirs numa cadeia de 3 redirecionamentos
+func TestMaxRedirs(t *testing.T) {
+	srv := chainServer(t)
+	tests := []struct {
+		maxRedirs  int
+		wantExit   int
+		wantStatus int
+		wantHops   int
+	}{
+		{10, 0, 200, 3},
+		{3, 0, 200, 3},
+		{2, exitRedirect, 0, 0},
+		{0, 0, 302, 0}, // Não segue: devolve o próprio 302
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		opts.json = true
+		opts.maxRedirs = tt.maxRedirs
+		s, stderr := fetchQuiet(t, srv.URL+"/r/3", opts)
+		if s.Exit != tt.wantExit || s.Status != tt.wantStatus || len(s.Redirects) != tt.wantHops {
+			t.Errorf("-max-redirs %d: código %d, status %d, %d passos; esperado %d, %d, %d (%s)",
+				tt.maxRedirs, s.Exit, s.Status, len(s.Redirects), tt.wantExit, tt.wantStatus, tt.wantHops, stderr)
+		}
+	}
+}
+
+// TestRedirectChain confere a cadeia do resumo e a impressa por -redirects
+func TestRedirectChain(t *testing.T) {
+	srv := chainServer(t)
+	opts := testOptions()
+	opts.json = true
+	opts.maxRedirs = 10
+	opts.redirects = true
+	s, stderr := fetchQuiet(t, srv.URL+"/r/2", opts)
+	want := []hop{
+		{srv.URL + "/r/2", 302, srv.URL + "/r/1"},
+		{srv.URL + "/r/1", 302, srv.URL + "/r/0"},
+	}
+	if len(s.Redirects) != len(want) {
+		t.Fatalf("cadeia %+v, esperada %+v", s.Redirects, want)
+	}
+	for i := range want {
+		if s.Redirects[i] != want[i] {
+			t.Errorf("passo %d: %+v, esperado %+v", i+1, s.Redirects[i], want[i])
+		}
+	}
+	if s.FinalURL != srv.URL+"/r/0" {
+		t.Errorf("URL final %s, esperada %s/r/0", s.FinalURL, srv.URL)
+	}
+	wantLines := fmt.Sprintf("redirecionamento 1: 302 Found %[1]s/r/2 -> %[1]s/r/1\n"+
+		"redirecionamento 2: 302 Found %[1]s/r/1 -> %[1]s/r/0\n", srv.URL)
+	if stderr != wantLines {
+		t.Errorf("-redirects imprimiu %q, esperado %q", stderr, wantLines)
+	}
+}
+
+// TestNoDowngrade confere -no-downgrade: um HTTPS que manda para HTTP é recusado
+func TestNoDowngrade(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "sem TLS")
+	}))
+	defer plain.Close()
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/destino", http.StatusMovedPermanently)
+	}))
+	defer secure.Close()
+
+	for _, noDowngrade := range []bool{false, true} {
+		opts := testOptions()
+		opts.json = true
+		opts.insecure = true
+		opts.maxRedirs = 10
+		opts.noDowngrade = noDowngrade
+		s, stderr := fetchQuiet(t, secure.URL, opts)
+		switch {
+		case noDowngrade && (s.Exit != exitRedirect || !strings.Contains(s.Error, "-no-downgrade")):
+			t.Errorf("com -no-downgrade: código %d, erro %q; esperado %d (%s)", s.Exit, s.Error, exitRedirect, stderr)
+		case !noDowngrade && (s.Exit != 0 || s.FinalURL != plain.URL+"/destino" || len(s.Redirects) != 1):
+			t.Errorf("sem -no-downgrade: código %d, URL final %s, cadeia %+v (%s)", s.Exit, s.FinalURL, s.Redirects, stderr)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// Montagem das requisições: método (-X), headers (-H), corpo (-d) e autenticação (-u, -bearer),
+// enviadas por um http.Client criado pelo programa em vez do http.DefaultClient do livro
+package main
+
+import (
+	"bytes"    // Corpo da requisição em memória
+	"context"  // Cancelamento da requisição
+	"errors"   // Mensagens de validação das flags
+	"fmt"      // Formatação das mensagens de validação
+	"io"       // Leitura do stdin e corpo genérico
+	"net/http" // Requisição, cliente e transporte
+	"os"       // Arquivo e stdin do corpo
+	"strings"  // Separação de "Nome: valor" e "usuario:senha"
+)
+
+// newClient cria o cliente HTTP usado em todas as buscas
+// O transporte é uma cópia do padrão (proxy das variáveis de ambiente, HTTP/2, pool de
+// conexões), mas pertence ao programa: as opções de rede podem alterá-lo sem afetar outros
+// usuários de http.DefaultTransport
+func newClient(opts options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if err := configureTransport(transport, opts); err != nil {
+		return nil, err
+	}
+	// Sem isto, o transporte pede gzip por conta própria e descomprime escondido; aqui a
+	// compressão só é pedida com -compressed (ou -H "Accept-Encoding: ..."), como no curl
+	transport.DisableCompression = true
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return checkRedirect(req, via, opts)
+		},
+	}, nil
+}
+
+// newRequest monta a requisição para url conforme as flags, ligada ao contexto ctx
+// Sem -X, o método é GET, HEAD com -I ou POST quando há corpo (como no curl)
+func newRequest(ctx context.Context, url string, opts options) (*http.Request, error) {
+	method := opts.method
+	switch {
+	case method != "":
+	case opts.headOnly:
+		method = http.MethodHead
+	case opts.body.set:
+		method = http.MethodPost
+	default:
+		method = http.MethodGet
+	}
+
+	body, size, err := opts.body.open()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		if body != nil {
+			body.Close()
+		}
+		return nil, err
+	}
+	// NewRequest só conhece o tamanho de corpos em memória; o de um arquivo vem do Stat
+	if body != nil {
+		req.ContentLength = size
+		// Sem Content-Type explícito, o corpo é enviado como formulário, como no curl -d
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	if opts.compressed {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	for _, h := range opts.headers {
+		// Host não é um header comum em net/http: fica em req.Host
+		if http.CanonicalHeaderKey(h.name) == "Host" {
+			req.Host = h.value
+			continue
+		}
+		req.Header.Set(h.name, h.value)
+	}
+	switch {
+	case opts.user != "":
+		user, pass, _ := strings.Cut(opts.user, ":")
+		req.SetBasicAuth(user, pass)
+	case opts.bearer != "":
+		req.Header.Set("Authorization", "Bearer "+opts.bearer)
+	}
+	return req, nil
+}
+
+// header é um header extra da requisição, passado em -H "Nome: valor"
+type header struct {
+	name, value string
+}
+
+// headerList acumula as ocorrências de -H; implementa flag.Value
+// Um nome repetido fica com o último valor
+type headerList []header
+
+// String implementa flag.Value
+func (l *headerList) String() string {
+	if l == nil {
+		return ""
+	}
+	parts := make([]string, len(*l))
+	for i, h := range *l {
+		parts[i] = h.name + ": " + h.value
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Set implementa flag.Value: aceita "Nome: valor" (o valor pode ser vazio)
+func (l *headerList) Set(s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	name = strings.TrimSpace(name)
+	if !ok || !isToken(name) {
+		return fmt.Errorf("header %q inválido (use \"Nome: valor\")", s)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("header %q contém quebra de linha", name)
+	}
+	*l = append(*l, header{name: name, value: strings.TrimSpace(value)})
+	return nil
+}
+
+// isToken informa se s é um "token" da RFC 9110, o formato de nomes de header e de métodos
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// requestBody é o corpo da requisição (-d): um texto, @arquivo ou @- para o stdin
+// Implementa flag.Value. O arquivo é reaberto a cada URL, sem ser carregado na memória;
+// o stdin só pode ser lido uma vez, então é guardado para servir a todas as URLs
+type requestBody struct {
+	set   bool   // -d foi informado
+	data  []byte // Texto literal ou conteúdo do stdin
+	path  string // Arquivo de @arquivo
+	stdin bool   // data veio do stdin (@-)
+}
+
+// String implementa flag.Value
+func (b *requestBody) String() string {
+	if b == nil || b.path == "" {
+		return ""
+	}
+	return "@" + b.path
+}
+
+// Set implementa flag.Value
+func (b *requestBody) Set(s string) error {
+	b.set = true
+	b.data, b.path, b.stdin = nil, "", false
+	switch {
+	case s == "@-":
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return fmt.Errorf("erro ao ler o corpo do stdin: %v", err)
+		}
+		b.data, b.stdin = data, true
+	case strings.HasPrefix(s, "@"):
+		b.path = s[1:]
+		if _, err := os.Stat(b.path); err != nil {
+			return fmt.Errorf("arquivo do corpo: %v", err)
+		}
+	default:
+		b.data = []byte(s)
+	}
+	return nil
+}
+
+// open devolve um leitor novo do corpo e o seu tamanho; (nil, 0, nil) sem -d
+func (b *requestBody) open() (io.ReadCloser, int64, error) {
+	switch {
+	case !b.set:
+		return nil, 0, nil
+	case b.path != "":
+		f, err := os.Open(b.path)
+		if err != nil {
+			return nil, 0, err
+		}
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, 0, err
+		}
+		return f, info.Size(), nil
+	}
+	return io.NopCloser(bytes.NewReader(b.data)), int64(len(b.data)), nil
+}
+
+// validateRequest confere as flags da requisição
+func (o options) validateRequest() error {
+	switch {
+	case o.method != "" && !isToken(o.method):
+		return fmt.Errorf("método %q inválido", o.method)
+	case o.headOnly && o.method != "" && o.method != http.MethodHead:
+		return errors.New("-I faz um HEAD; não use com -X")
+	case o.headOnly && o.body.set:
+		return errors.New("-I faz um HEAD, que não envia corpo; não use com -d")
+	case o.user != "" && o.bearer != "":
+		return errors.New("use -u ou -bearer, não os dois")
+	case o.user != "" && !strings.Contains(o.user, ":"):
+		return errors.New(`-u precisa de "usuario:senha"`)
+	}
+	return nil
+}
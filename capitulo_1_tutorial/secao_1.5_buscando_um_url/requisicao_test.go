@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNewRequestMethod confere o método escolhido sem e com -X
+func TestNewRequestMethod(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*options)
+		want string
+	}{
+		{"padrão", func(o *options) {}, http.MethodGet},
+		{"-I", func(o *options) { o.headOnly = true }, http.MethodHead},
+		{"-d", func(o *options) { o.body = requestBody{set: true, data: []byte("a=1")} }, http.MethodPost},
+		{"-X PUT com -d", func(o *options) {
+			o.method, o.body = http.MethodPut, requestBody{set: true, data: []byte("a=1")}
+		}, http.MethodPut},
+		{"-X DELETE", func(o *options) { o.method = http.MethodDelete }, http.MethodDelete},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		tt.set(&opts)
+		req, err := newRequest(context.Background(), "http://gopl.io", opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if req.Method != tt.want {
+			t.Errorf("%s: método %s, esperado %s", tt.name, req.Method, tt.want)
+		}
+	}
+}
+
+// TestRequestSent confere, do lado do servidor, o que -H, -d @arquivo, -u e -bearer enviam
+func TestRequestSent(t *testing.T) {
+	var got *http.Request
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), "corpo.txt")
+	if err := os.WriteFile(file, []byte("nome=gopher&livro=gopl"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// -d @arquivo: POST de formulário, com o tamanho do arquivo
+	opts := testOptions()
+	opts.json = true
+	opts.body = requestBody{set: true, path: file}
+	if s, stderr := fetchQuiet(t, srv.URL, opts); s.Exit != 0 {
+		t.Fatalf("-d @arquivo: código %d (%s)", s.Exit, stderr)
+	}
+	if got.Method != http.MethodPost || body != "nome=gopher&livro=gopl" || got.ContentLength != 22 ||
+		got.Header.Get("Content-Type") != "application/x-www-form-urlencoded" {
+		t.Errorf("-d @arquivo: %s de %d bytes %q (%s)", got.Method, got.ContentLength, body, got.Header.Get("Content-Type"))
+	}
+
+	// -H: um Content-Type próprio substitui o de formulário, e Host vai para req.Host
+	opts.headers = nil
+	for _, h := range []string{"Content-Type: application/json", "X-Teste: 1", "x-teste: 2", "Host: exemplo.com"} {
+		if err := opts.headers.Set(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fetchQuiet(t, srv.URL, opts)
+	if ct := got.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("-H Content-Type: recebido %q", ct)
+	}
+	if v := got.Header.Values("X-Teste"); len(v) != 1 || v[0] != "2" {
+		t.Errorf("-H repetido: recebido %q, esperado só o último valor", v)
+	}
+	if got.Host != "exemplo.com" || got.Header.Get("Host") != "" {
+		t.Errorf("-H Host: Host %q, header %q", got.Host, got.Header.Get("Host"))
+	}
+
+	// -u e -bearer
+	opts = testOptions()
+	opts.json = true
+	opts.user = "gopher:s3nh@:x"
+	fetchQuiet(t, srv.URL, opts)
+	if user, pass, ok := got.BasicAuth(); !ok || user != "gopher" || pass != "s3nh@:x" {
+		t.Errorf("-u: recebido %q, %q, %v", user, pass, ok)
+	}
+	opts.user, opts.bearer = "", "abc.def"
+	fetchQuiet(t, srv.URL, opts)
+	if auth := got.Header.Get("Authorization"); auth != "Bearer abc.def" {
+		t.Errorf("-bearer: Authorization %q", auth)
+	}
+}
+
+// TestHeaderListSet confere os formatos aceitos e recusados por -H
+func TestHeaderListSet(t *testing.T) {
+	tests := []struct {
+		in          string
+		name, value string
+	}{
+		{"Accept: text/html", "Accept", "text/html"},
+		{"  X-Vazio :", "X-Vazio", ""},
+		{"X-Hora: 12:30", "X-Hora", "12:30"},
+	}
+	for _, tt := range tests {
+		var l headerList
+		if err := l.Set(tt.in); err != nil || len(l) != 1 || l[0] != (header{tt.name, tt.value}) {
+			t.Errorf("Set(%q) = %v, %v; esperado %s: %q", tt.in, l, err, tt.name, tt.value)
+		}
+	}
+	for _, bad := range []string{"", "Accept", ": valor", "Com espaço: x", "X-Ç: 1", "X-A: 1\r\nX-B: 2"} {
+		var l headerList
+		if err := l.Set(bad); err == nil {
+			t.Errorf("Set(%q) aceito, esperado erro", bad)
+		}
+	}
+}
+
+// TestValidateRequest confere as combinações recusadas de -X, -I, -d, -u e -bearer
+func TestValidateRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*options)
+		ok   bool
+	}{
+		{"-X PATCH", func(o *options) { o.method = "PATCH" }, true},
+		{"-X com espaço", func(o *options) { o.method = "GET X" }, false},
+		{"-I com -X HEAD", func(o *options) { o.headOnly, o.method = true, http.MethodHead }, true},
+		{"-I com -X GET", func(o *options) { o.headOnly, o.method = true, http.MethodGet }, false},
+		{"-I com -d", func(o *options) { o.headOnly, o.body.set = true, true }, false},
+		{"-u com senha", func(o *options) { o.user = "a:b" }, true},
+		{"-u com senha vazia", func(o *options) { o.user = "a:" }, true},
+		{"-u sem senha", func(o *options) { o.user = "a" }, false},
+		{"-u e -bearer", func(o *options) { o.user, o.bearer = "a:b", "t" }, false},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		tt.set(&opts)
+		if err := opts.validateRequest(); (err == nil) != tt.ok {
+			t.Errorf("%s: validateRequest = %v, esperado ok = %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Metadados das respostas: linha de status e headers (-i/-I) e o resumo em JSON (-json)
+package main
+
+import (
+	"fmt"      // Mensagens de erro no stderr
+	"io"       // Destino da linha de status e dos headers
+	"net/http" // Resposta de onde vêm os metadados
+	"os"       // Stderr
+	"time"     // Tempo até os headers
+)
+
+// summary é o resumo de uma busca, escrito como uma linha de JSON por URL com -json
+// Tempos em segundos, como no programa da seção 1.6
+type summary struct {
+	URL           string  `json:"url"`                    // URL pedida (já normalizada)
+	Source        string  `json:"source,omitempty"`       // Com -urls, "arquivo:linha" de onde veio a URL
+	Method        string  `json:"method,omitempty"`       // Método da requisição
+	FinalURL      string  `json:"final_url,omitempty"`    // URL que respondeu, depois dos redirecionamentos
+	Redirects     []hop   `json:"redirects,omitempty"`    // Cadeia de redirecionamentos seguida até FinalURL
+	Status        int     `json:"status,omitempty"`       // Código HTTP (0 se não houve resposta)
+	Proto         string  `json:"proto,omitempty"`        // Versão do protocolo, ex.: "HTTP/1.1"
+	ContentType   string  `json:"content_type,omitempty"` // Header Content-Type
+	ContentLength int64   `json:"content_length"`         // Header Content-Length (-1 = desconhecido)
+	Bytes         int64   `json:"bytes"`                  // Bytes do corpo efetivamente recebidos
+	Resumed       int64   `json:"resumed_from,omitempty"` // Com -C, bytes que já estavam no .part
+	Output        string  `json:"output,omitempty"`       // Arquivo ou "stdout"; vazio se o corpo foi descartado
+	TimeHeaders   float64 `json:"time_headers"`           // Do início da busca até os headers chegarem
+	TimeTotal     float64 `json:"time_total"`             // Do início da busca até o fim do corpo
+	Attempts      int     `json:"attempts"`               // Tentativas feitas (1 + novas tentativas de -retry; 0 = cache)
+	Cache         string  `json:"cache,omitempty"`        // Com -cache: "hit", "revalidated" ou "miss"
+	Error         string  `json:"error,omitempty"`        // Mensagem da falha, se houve
+	Exit          int     `json:"exit_code"`              // Bits de saída desta URL (0 = sucesso)
+}
+
+// record guarda no resumo os metadados da resposta; elapsed é o tempo até os headers
+func (s *summary) record(resp *http.Response, elapsed time.Duration) {
+	s.Method = resp.Request.Method
+	s.FinalURL = resp.Request.URL.String()
+	s.Redirects = redirectChain(resp)
+	s.Status = resp.StatusCode
+	s.Proto = resp.Proto
+	s.ContentType = resp.Header.Get("Content-Type")
+	s.ContentLength = resp.ContentLength
+	s.TimeHeaders = elapsed.Seconds()
+}
+
+// fail imprime a falha no stderr e a registra no resumo com os bits de saída code
+func (s *summary) fail(code int, err error) {
+	fmt.Fprintf(os.Stderr, "Erro ao buscar %s: %v\n", s.URL, err)
+	s.Exit |= code
+	s.Error = err.Error()
+}
+
+// writeHead escreve a linha de status e os headers da resposta, terminados por uma linha
+// vazia, no formato em que chegam pela rede (como curl -i). Header.Write ordena os nomes
+func writeHead(w io.Writer, resp *http.Response) error {
+	if _, err := fmt.Fprintf(w, "%s %s\r\n", resp.Proto, resp.Status); err != nil {
+		return err
+	}
+	if err := resp.Header.Write(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\r\n")
+	return err
+}
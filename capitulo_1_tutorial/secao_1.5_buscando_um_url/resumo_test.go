@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// headServer responde "corpo" com um header próprio e guarda o método recebido
+func headServer(t *testing.T, method *string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*method = r.Method
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Teste", "sim")
+		io.WriteString(w, "corpo")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fetchStdout chama fetch e devolve o resumo e o que foi escrito no stdout
+func fetchStdout(t *testing.T, url string, opts options) (summary, string) {
+	t.Helper()
+	var s summary
+	stdout := capture(t, &os.Stdout, func() { s, _ = fetchQuiet(t, url, opts) })
+	return s, stdout
+}
+
+// TestFetchHeadOnly confere -I: um HEAD, com a linha de status e os headers e sem corpo
+func TestFetchHeadOnly(t *testing.T) {
+	var method string
+	srv := headServer(t, &method)
+	opts := testOptions()
+	opts.headOnly = true
+	s, out := fetchStdout(t, srv.URL, opts)
+	if method != http.MethodHead || s.Method != http.MethodHead {
+		t.Errorf("método %q (resumo %q), esperado HEAD", method, s.Method)
+	}
+	if !strings.HasPrefix(out, "HTTP/1.1 200 OK\r\n") || !strings.Contains(out, "X-Teste: sim\r\n") {
+		t.Errorf("saída %q sem a linha de status ou o header X-Teste", out)
+	}
+	if !strings.HasSuffix(out, "\r\n\r\n") || strings.Contains(out, "corpo") || s.Bytes != 0 {
+		t.Errorf("saída %q (%d bytes de corpo), esperados só os headers", out, s.Bytes)
+	}
+}
+
+// TestFetchHead confere -i: os headers, uma linha vazia e o corpo, no mesmo destino
+func TestFetchHead(t *testing.T) {
+	var method string
+	srv := headServer(t, &method)
+	opts := testOptions()
+	opts.head = true
+	s, out := fetchStdout(t, srv.URL, opts)
+	head, body, ok := strings.Cut(out, "\r\n\r\n")
+	if method != http.MethodGet || !ok || !strings.Contains(head, "X-Teste: sim") || body != "corpo" {
+		t.Errorf("%s: saída %q, esperados os headers e o corpo", method, out)
+	}
+	if s.Bytes != 5 || s.Output != "stdout" {
+		t.Errorf("resumo com %d bytes em %q, esperado 5 em stdout", s.Bytes, s.Output)
+	}
+}
+
+// TestSummaryJSON confere os campos do resumo de -json, com o corpo em -o
+func TestSummaryJSON(t *testing.T) {
+	var method string
+	srv := headServer(t, &method)
+	opts := testOptions()
+	opts.json = true
+	opts.output = filepath.Join(t.TempDir(), "corpo.txt")
+	s, out := fetchStdout(t, srv.URL, opts)
+	if out != "" {
+		t.Errorf("fetch escreveu %q no stdout, que com -json é do resumo", out)
+	}
+	if s.URL != srv.URL || s.FinalURL != srv.URL || s.Method != "GET" || s.Status != 200 ||
+		s.Proto != "HTTP/1.1" || s.ContentType != "text/plain; charset=utf-8" || s.ContentLength != 5 ||
+		s.Bytes != 5 || s.Output != opts.output || s.Attempts != 1 || s.Exit != 0 || s.Error != "" {
+		t.Errorf("resumo %+v", s)
+	}
+	if s.TimeHeaders <= 0 || s.TimeTotal < s.TimeHeaders {
+		t.Errorf("tempos %g e %g, esperado 0 < headers <= total", s.TimeHeaders, s.TimeTotal)
+	}
+	if data, err := os.ReadFile(opts.output); err != nil || string(data) != "corpo" {
+		t.Errorf("-o recebeu %q, %v; esperado o corpo", data, err)
+	}
+
+	// Os nomes dos campos são a interface com os scripts
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	for _, name := range []string{"url", "method", "final_url", "status", "proto", "content_type",
+		"content_length", "bytes", "output", "time_headers", "time_total", "attempts", "exit_code"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("campo %q ausente em %s", name, data)
+		}
+	}
+	for _, name := range []string{"error", "redirects", "source", "cache", "resumed_from"} {
+		if _, ok := fields[name]; ok {
+			t.Errorf("campo %q presente em %s, esperado omitido quando vazio", name, data)
+		}
+	}
+}
+
+// TestSummaryJSONFailure confere o resumo de uma URL que falhou: sem status, com o erro
+func TestSummaryJSONFailure(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	opts := testOptions()
+	opts.json = true
+	s, _ := fetchQuiet(t, srv.URL, opts)
+	data, _ := json.Marshal(s)
+	var got struct {
+		Status *int   `json:"status"`
+		Length int64  `json:"content_length"`
+		Error  string `json:"error"`
+		Exit   int    `json:"exit_code"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != nil || got.Length != -1 || got.Error == "" || got.Exit != exitNetwork {
+		t.Errorf("resumo %s, esperado sem status, content_length -1, com erro e exit_code %d", data, exitNetwork)
+	}
+}
+
+// TestWriteHead confere o formato de -i/-I: status, headers em ordem e linha vazia
+func TestWriteHead(t *testing.T) {
+	resp := &http.Response{
+		Proto:  "HTTP/2.0",
+		Status: "404 Not Found",
+		Header: http.Header{"X-B": {"2"}, "X-A": {"1"}},
+	}
+	var b strings.Builder
+	if err := writeHead(&b, resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := "HTTP/2.0 404 Not Found\r\nX-A: 1\r\nX-B: 2\r\n\r\n"; b.String() != want {
+		t.Errorf("writeHead = %q, esperado %q", b.String(), want)
+	}
+}
@@ -0,0 +1,339 @@
+// Downloads retomáveis (-C): o corpo vai para arquivo.part e, depois de uma falha, a busca
+// continua de onde parou com Range e If-Range, em vez de baixar tudo de novo. O ETag ou o
+// Last-Modified guardados ao lado garantem que os pedaços são da mesma versão do arquivo
+package main
+
+import (
+	"bufio"         // Leitor do arquivo de validadores
+	"context"       // Cancelamento das buscas e das esperas
+	"errors"        // Erros de download incompleto e de Content-Range
+	"fmt"           // Mensagens e headers Range
+	"io"            // Cópia do corpo
+	"net/http"      // Status 200, 206 e 416 e headers das respostas
+	"net/textproto" // Leitura do arquivo de validadores, no formato de headers
+	"os"            // Arquivos .part e de validadores
+	"strconv"       // Números do Content-Range e do tamanho total
+	"strings"       // Partes do Content-Range e ETags fracos
+	"time"          // Tempos do resumo e espera antes de retomar
+)
+
+var (
+	// errIncomplete indica que o corpo terminou antes do tamanho anunciado; o .part é mantido
+	errIncomplete = errors.New("download incompleto")
+	// errRestart indica que o .part não serve mais e foi apagado: a busca recomeça do zero
+	errRestart = errors.New("recomeçando do zero")
+)
+
+// partial é o estado de um download retomável de path
+// Os bytes já recebidos ficam em path+".part"; os validadores, em path+".part.headers"
+type partial struct {
+	path         string
+	size         int64  // Bytes já gravados no .part
+	url          string // URL que gerou o .part; outra URL recomeça do zero
+	etag         string // ETag forte da resposta original (os fracos não valem para If-Range)
+	lastModified string // Last-Modified da resposta original
+	total        int64  // Tamanho completo do arquivo (-1 = desconhecido)
+}
+
+func (p *partial) partPath() string    { return p.path + ".part" }
+func (p *partial) headersPath() string { return p.path + ".part.headers" }
+
+// loadPartial lê o estado do download de path para url
+// Sem .part, sem validadores ou com validadores de outra URL, o download começa do zero
+func loadPartial(path, url string) *partial {
+	p := &partial{path: path, url: url, total: -1}
+	info, err := os.Stat(p.partPath())
+	if err != nil {
+		return p
+	}
+	f, err := os.Open(p.headersPath())
+	if err != nil {
+		return p
+	}
+	defer f.Close()
+	h, err := textproto.NewReader(bufio.NewReader(f)).ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return p
+	}
+	if h.Get("Url") != url {
+		return p
+	}
+	p.size = info.Size()
+	p.etag = h.Get("Etag")
+	p.lastModified = h.Get("Last-Modified")
+	if n, err := strconv.ParseInt(h.Get("Content-Length"), 10, 64); err == nil {
+		p.total = n
+	}
+	return p
+}
+
+// validator devolve o valor de If-Range: o ETag forte ou, na falta dele, o Last-Modified
+// Vazio quando não há como saber se o arquivo mudou no servidor: retomar seria arriscado
+func (p *partial) validator() string {
+	if p.etag != "" && !strings.HasPrefix(p.etag, "W/") {
+		return p.etag
+	}
+	return p.lastModified
+}
+
+// saveHeaders grava os validadores da resposta que começa (ou recomeça) o .part
+func (p *partial) saveHeaders() error {
+	h := http.Header{}
+	h.Set("Url", p.url)
+	if p.etag != "" {
+		h.Set("Etag", p.etag)
+	}
+	if p.lastModified != "" {
+		h.Set("Last-Modified", p.lastModified)
+	}
+	if p.total >= 0 {
+		h.Set("Content-Length", strconv.FormatInt(p.total, 10))
+	}
+	f, err := os.Create(p.headersPath())
+	if err != nil {
+		return err
+	}
+	if err := h.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// discard apaga o .part e os validadores, para o próximo download começar do zero
+func (p *partial) discard() {
+	os.Remove(p.partPath())
+	os.Remove(p.headersPath())
+	p.size, p.total = 0, -1
+}
+
+// complete troca o arquivo final pelo .part, agora completo, e apaga os validadores
+func (p *partial) complete() error {
+	if err := os.Rename(p.partPath(), p.path); err != nil {
+		return err
+	}
+	os.Remove(p.headersPath())
+	return nil
+}
+
+// download busca url para o arquivo de -o/-O, retomando o .part deixado por uma execução
+// anterior e, com -retry, retomando também as quedas no meio do corpo desta execução
+func download(ctx context.Context, client *http.Client, url string, opts options) (s summary) {
+	s = summary{URL: url, ContentLength: -1}
+	start := time.Now()
+	defer func() { s.TimeTotal = time.Since(start).Seconds() }()
+
+	// Com -C, -O não escolhe um nome novo quando o arquivo existe: a próxima execução
+	// precisa achar o mesmo .part
+	path := opts.output
+	if opts.remoteName {
+		path = remoteName(url)
+	}
+	s.Output = path
+
+	p := loadPartial(path, url)
+	s.Resumed = p.size
+	// Cada queda no meio do corpo gasta uma das novas tentativas de -retry; recomeçar do zero
+	// porque o .part não serve mais acontece uma vez, na hora, sem gastar nenhuma
+	restarted := false
+	for retries := 0; ; p = loadPartial(path, url) {
+		n, err := p.fetch(ctx, client, url, opts, &s, start)
+		s.Bytes += n
+		switch {
+		case err == nil:
+			if !opts.quiet {
+				fmt.Fprintf(os.Stderr, "%s: %d bytes gravados em %s\n", url, p.size, path)
+			}
+			return s
+		case errors.Is(err, errRestart) && !restarted && ctx.Err() == nil:
+			restarted = true
+			opts.logf("%s: %v\n", url, err)
+			continue
+		case !errors.Is(err, errIncomplete) || retries >= opts.retry || ctx.Err() != nil:
+			if errors.Is(err, errIncomplete) {
+				err = fmt.Errorf("%v; rode de novo com -C para continuar", err)
+			}
+			if s.Exit == 0 {
+				s.fail(exitRead, err)
+			}
+			return s
+		}
+		wait := backoff(retries, opts.retryDelay, opts.retryMaxDelay)
+		retries++
+		opts.logf("%s: %v; retomando em %s\n", url, err, wait.Round(time.Millisecond))
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			s.fail(exitNetwork, context.Cause(ctx))
+			return s
+		}
+	}
+}
+
+// fetch faz uma rodada do download: pede o que falta do .part e grava o que chegar
+// Devolve os bytes recebidos; errIncomplete e errRestart pedem outra rodada, e as demais
+// falhas já ficam registradas em s
+func (p *partial) fetch(ctx context.Context, client *http.Client, url string, opts options, s *summary, start time.Time) (n int64, err error) {
+	// Range pede o resto; If-Range faz o servidor mandar o arquivo inteiro (200) em vez do
+	// resto (206) se a versão mudou, para nunca juntar pedaços de versões diferentes
+	if p.size > 0 {
+		if v := p.validator(); v != "" {
+			opts.headers = append(append(headerList(nil), opts.headers...),
+				header{"Range", fmt.Sprintf("bytes=%d-", p.size)}, header{"If-Range", v})
+		} else {
+			opts.logf("%s: %s sem ETag nem Last-Modified; recomeçando do zero\n", url, p.partPath())
+			p.discard()
+		}
+	}
+
+	resp, stop, attempts, err := send(ctx, client, url, opts)
+	defer stop()
+	s.Attempts += attempts
+	if err != nil {
+		s.fail(sendExitCode(err), err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+	s.record(resp, time.Since(start))
+	announce(url, s, resp, opts)
+
+	var flags int
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		first, _, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || first != p.size {
+			// Um pedaço que não começa onde o .part termina não pode ser emendado
+			err := fmt.Errorf("%w: Content-Range %q não continua os %d bytes já baixados",
+				errRestart, resp.Header.Get("Content-Range"), p.size)
+			p.discard()
+			return 0, err
+		}
+		if total >= 0 {
+			p.total = total
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+	case http.StatusOK:
+		if p.size > 0 {
+			opts.logf("%s: o servidor mandou o arquivo inteiro (mudou ou não aceita Range); recomeçando do zero\n", url)
+		}
+		p.size, p.total = 0, resp.ContentLength
+		p.etag, p.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if err := p.saveHeaders(); err != nil {
+			s.fail(exitRead, fmt.Errorf("ao gravar %s: %v", p.headersPath(), err))
+			return 0, err
+		}
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// O .part pode já estar completo (a execução anterior caiu antes de renomeá-lo)
+		if _, _, total, ok := parseContentRange(resp.Header.Get("Content-Range")); ok && total == p.size && p.size > 0 {
+			return 0, p.finish(s)
+		}
+		err := fmt.Errorf("%w: o servidor recusou o Range %d-: %s", errRestart, p.size, resp.Status)
+		p.discard()
+		return 0, err
+	default:
+		// Uma página de erro não pode ir para o arquivo: o .part fica como está
+		code := statusExitCode(resp.StatusCode)
+		if code == 0 {
+			code = exitRead
+		}
+		err := fmt.Errorf("o servidor respondeu %s; -C só grava respostas 200 e 206", resp.Status)
+		s.fail(code, err)
+		return 0, err
+	}
+
+	// O limite vale para o arquivo inteiro, não só para o pedaço desta rodada
+	if opts.maxSize > 0 && (p.total > int64(opts.maxSize) || p.size >= int64(opts.maxSize)) {
+		err := fmt.Errorf("tamanho total de %s bytes passa de -max-size %s", totalString(max(p.total, p.size)), &opts.maxSize)
+		p.discard()
+		s.fail(exitTooLarge, err)
+		return 0, err
+	}
+	f, err := os.OpenFile(p.partPath(), flags, 0o644)
+	if err != nil {
+		s.fail(exitRead, fmt.Errorf("ao abrir %s: %v", p.partPath(), err))
+		return 0, err
+	}
+	limit := int64(0)
+	if opts.maxSize > 0 {
+		limit = int64(opts.maxSize) - p.size
+	}
+	n, err = copyBody(f, resp.Body, limit)
+	p.size += n
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	switch {
+	case errors.Is(err, errTooLarge):
+		p.discard()
+		s.fail(exitTooLarge, fmt.Errorf("download abortado após %d bytes: %v %s", n, err, &opts.maxSize))
+		return n, err
+	case err != nil:
+		// O que chegou fica no .part: a próxima rodada (ou execução) continua dali
+		return n, fmt.Errorf("%w: %d de %s bytes em %s (%v)", errIncomplete, p.size, totalString(p.total), p.partPath(), err)
+	}
+	return n, p.finish(s)
+}
+
+// finish confere o tamanho do .part com o total anunciado e, se bater, o renomeia
+func (p *partial) finish(s *summary) error {
+	switch {
+	case p.total >= 0 && p.size < p.total:
+		return fmt.Errorf("%w: %d de %d bytes em %s", errIncomplete, p.size, p.total, p.partPath())
+	case p.total >= 0 && p.size > p.total:
+		p.discard()
+		err := fmt.Errorf("recebidos %d bytes, mais que os %d anunciados; arquivo descartado", p.size, p.total)
+		s.fail(exitRead, err)
+		return err
+	}
+	if err := p.complete(); err != nil {
+		err = fmt.Errorf("ao renomear %s: %v", p.partPath(), err)
+		s.fail(exitRead, err)
+		return err
+	}
+	return nil
+}
+
+// totalString formata o tamanho total para as mensagens ("?" se desconhecido)
+func totalString(total int64) string {
+	if total < 0 {
+		return "?"
+	}
+	return strconv.FormatInt(total, 10)
+}
+
+// parseContentRange interpreta "bytes 100-199/200"; total é -1 em "bytes 100-199/*"
+// Para "bytes */200" (resposta 416), first e last são -1
+func parseContentRange(v string) (first, last, total int64, ok bool) {
+	rng, ok := strings.CutPrefix(v, "bytes ")
+	if !ok {
+		return 0, 0, 0, false
+	}
+	rng, size, ok := strings.Cut(rng, "/")
+	if !ok {
+		return 0, 0, 0, false
+	}
+	total = -1
+	if size != "*" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, 0, false
+		}
+		total = n
+	}
+	if rng == "*" {
+		return -1, -1, total, total >= 0
+	}
+	a, b, ok := strings.Cut(rng, "-")
+	if !ok {
+		return 0, 0, 0, false
+	}
+	first, err1 := strconv.ParseInt(a, 10, 64)
+	last, err2 := strconv.ParseInt(b, 10, 64)
+	if err1 != nil || err2 != nil || first < 0 || last < first || (total >= 0 && last >= total) {
+		return 0, 0, 0, false
+	}
+	return first, last, total, true
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Conteúdo servido nos testes de -C
+const resumeContent = "0123456789abcdefghij"
+
+// rangeServer serve *content com o ETag *etag por http.ServeContent, que trata Range e
+// If-Range; ranges guarda o header Range de cada requisição recebida
+func rangeServer(t *testing.T, content, etag *string, ranges *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", *etag)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(*content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// writePartial cria o .part de path com data e os validadores de url, como uma execução
+// anterior interrompida
+func writePartial(t *testing.T, path, url, etag, data string, total int64) {
+	t.Helper()
+	p := &partial{path: path, url: url, etag: etag, total: total}
+	if err := os.WriteFile(p.partPath(), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.saveHeaders(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// resumeOptions devolve as opções de -C -o path
+func resumeOptions(path string) options {
+	opts := testOptions()
+	opts.resume = true
+	opts.output = path
+	return opts
+}
+
+// checkDownloaded confere o arquivo final e que o .part e os validadores sumiram
+func checkDownloaded(t *testing.T, path, want string) {
+	t.Helper()
+	if data, err := os.ReadFile(path); err != nil || string(data) != want {
+		t.Errorf("%s tem %q, %v; esperado %q", path, data, err, want)
+	}
+	for _, name := range []string{path + ".part", path + ".part.headers"} {
+		if _, err := os.Stat(name); err == nil {
+			t.Errorf("%s ficou depois do download completo", name)
+		}
+	}
+}
+
+// TestResumeAppend confere a retomada de um .part: um 206 com o resto, emendado no fim
+func TestResumeAppend(t *testing.T) {
+	content, etag := resumeContent, `"v1"`
+	var ranges []string
+	srv := rangeServer(t, &content, &etag, &ranges)
+	path := filepath.Join(t.TempDir(), "arquivo")
+	writePartial(t, path, srv.URL, etag, resumeContent[:8], 20)
+
+	s, stderr := fetchQuiet(t, srv.URL, resumeOptions(path))
+	if s.Exit != 0 || s.Status != http.StatusPartialContent || s.Resumed != 8 || s.Bytes != 12 {
+		t.Errorf("código %d, status %d, retomado de %d, %d bytes; esperado 0, 206, 8, 12 (%s)",
+			s.Exit, s.Status, s.Resumed, s.Bytes, stderr)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=8-" {
+		t.Errorf("Range enviados %q, esperado só bytes=8-", ranges)
+	}
+	checkDownloaded(t, path, resumeContent)
+}
+
+// TestResumeChanged confere que um ETag diferente faz o servidor mandar o arquivo novo
+// inteiro (200, por causa do If-Range), que substitui o .part em vez de ser emendado
+func TestResumeChanged(t *testing.T) {
+	content, etag := strings.ToUpper(resumeContent), `"v2"`
+	var ranges []string
+	srv := rangeServer(t, &content, &etag, &ranges)
+	path := filepath.Join(t.TempDir(), "arquivo")
+	writePartial(t, path, srv.URL, `"v1"`, resumeContent[:8], 20)
+
+	s, stderr := fetchQuiet(t, srv.URL, resumeOptions(path))
+	if s.Exit != 0 || s.Status != http.StatusOK || s.Bytes != 20 {
+		t.Errorf("código %d, status %d, %d bytes; esperado 0, 200, 20 (%s)", s.Exit, s.Status, s.Bytes, stderr)
+	}
+	checkDownloaded(t, path, content)
+}
+
+// TestResumeComplete confere o .part que já estava completo: o 416 com "bytes */20" basta
+// para renomeá-lo, sem baixar nada
+func TestResumeComplete(t *testing.T) {
+	content, etag := resumeContent, `"v1"`
+	var ranges []string
+	srv := rangeServer(t, &content, &etag, &ranges)
+	path := filepath.Join(t.TempDir(), "arquivo")
+	writePartial(t, path, srv.URL, etag, resumeContent, 20)
+
+	s, stderr := fetchQuiet(t, srv.URL, resumeOptions(path))
+	if s.Exit != 0 || s.Status != http.StatusRequestedRangeNotSatisfiable || s.Bytes != 0 {
+		t.Errorf("código %d, status %d, %d bytes; esperado 0, 416, 0 (%s)", s.Exit, s.Status, s.Bytes, stderr)
+	}
+	checkDownloaded(t, path, resumeContent)
+}
+
+// TestResumeBadContentRange confere que um 206 que não continua o .part (ou com
+// Content-Range ilegível) não é emendado: o .part é apagado e o download recomeça do zero
+func TestResumeBadContentRange(t *testing.T) {
+	for _, contentRange := range []string{"bytes 0-19/20", "bytes 10-19/20", "bytes 8-19", "itens 8-19/20"} {
+		var ranges []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ranges = append(ranges, r.Header.Get("Range"))
+			if r.Header.Get("Range") == "" {
+				io.WriteString(w, resumeContent)
+				return
+			}
+			w.Header().Set("Content-Range", contentRange)
+			w.WriteHeader(http.StatusPartialContent)
+			io.WriteString(w, resumeContent[8:])
+		}))
+		path := filepath.Join(t.TempDir(), "arquivo")
+		writePartial(t, path, srv.URL, `"v1"`, resumeContent[:8], 20)
+
+		s, stderr := fetchQuiet(t, srv.URL, resumeOptions(path))
+		srv.Close()
+		if s.Exit != 0 || s.Status != http.StatusOK || len(ranges) != 2 || ranges[1] != "" {
+			t.Errorf("Content-Range %q: código %d, status %d, Range enviados %q; esperado 0, 200 e um recomeço sem Range (%s)",
+				contentRange, s.Exit, s.Status, ranges, stderr)
+		}
+		checkDownloaded(t, path, resumeContent)
+	}
+}
+
+// TestResumeAfterDrop confere a retomada depois de uma queda no meio do corpo: na execução
+// seguinte ou, com -retry, na mesma, pedindo só o que falta
+func TestResumeAfterDrop(t *testing.T) {
+	var ranges []string
+	drop := true // A próxima resposta cai no meio
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		if drop {
+			drop = false
+			// Anuncia os 20 bytes, manda 8 e derruba a conexão
+			w.Header().Set("Content-Length", "20")
+			io.WriteString(w, resumeContent[:8])
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(resumeContent))
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "arquivo")
+
+	// Sem -retry, o .part fica para a próxima execução
+	s, _ := fetchQuiet(t, srv.URL, resumeOptions(path))
+	if s.Exit != exitRead || !strings.Contains(s.Error, "-C") {
+		t.Errorf("sem -retry: código %d, erro %q; esperado %d e a dica de rodar com -C", s.Exit, s.Error, exitRead)
+	}
+	if data, err := os.ReadFile(path + ".part"); err != nil || string(data) != resumeContent[:8] {
+		t.Errorf(".part com %q, %v; esperados os 8 primeiros bytes", data, err)
+	}
+
+	// A execução seguinte retoma dali
+	ranges = ranges[:0]
+	if s, stderr := fetchQuiet(t, srv.URL, resumeOptions(path)); s.Exit != 0 || s.Resumed != 8 || s.Bytes != 12 {
+		t.Errorf("segunda execução: código %d, retomado de %d, %d bytes; esperado 0, 8, 12 (%s)",
+			s.Exit, s.Resumed, s.Bytes, stderr)
+	}
+	if len(ranges) != 1 || ranges[0] != "bytes=8-" {
+		t.Errorf("Range enviados %q, esperado só bytes=8-", ranges)
+	}
+	checkDownloaded(t, path, resumeContent)
+
+	// Com -retry, a mesma execução retoma, sem o .part ficar para depois
+	path = filepath.Join(t.TempDir(), "arquivo")
+	ranges, drop = ranges[:0], true
+	opts := resumeOptions(path)
+	opts.retry = 1
+	if s, stderr := fetchQuiet(t, srv.URL, opts); s.Exit != 0 || s.Bytes != 20 {
+		t.Errorf("com -retry: código %d, %d bytes; esperado 0 e 20 (%s)", s.Exit, s.Bytes, stderr)
+	}
+	if len(ranges) != 2 || ranges[0] != "" || ranges[1] != "bytes=8-" {
+		t.Errorf("Range enviados %q, esperados nenhum e depois bytes=8-", ranges)
+	}
+	checkDownloaded(t, path, resumeContent)
+}
+
+// TestParseContentRange confere os formatos de Content-Range aceitos e recusados
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		in                 string
+		first, last, total int64
+		ok                 bool
+	}{
+		{"bytes 0-99/200", 0, 99, 200, true},
+		{"bytes 100-199/200", 100, 199, 200, true},
+		{"bytes 100-199/*", 100, 199, -1, true},
+		{"bytes */200", -1, -1, 200, true},
+		{"bytes */*", 0, 0, 0, false},
+		{"bytes 100-199/150", 0, 0, 0, false}, // Termina depois do total
+		{"bytes 199-100/200", 0, 0, 0, false},
+		{"bytes -1-99/200", 0, 0, 0, false},
+		{"bytes 0-99/-5", 0, 0, 0, false},
+		{"bytes 0-99", 0, 0, 0, false},
+		{"bytes 0/200", 0, 0, 0, false},
+		{"bytes a-b/200", 0, 0, 0, false},
+		{"items 0-99/200", 0, 0, 0, false},
+		{"", 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		first, last, total, ok := parseContentRange(tt.in)
+		if ok != tt.ok || ok && (first != tt.first || last != tt.last || total != tt.total) {
+			t.Errorf("parseContentRange(%q) = %d, %d, %d, %v; esperado %d, %d, %d, %v",
+				tt.in, first, last, total, ok, tt.first, tt.last, tt.total, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Destino do corpo das respostas: stdout (como no livro), o arquivo de -o ou um arquivo
+// com o nome derivado da URL (-O), sempre copiado em partes, sem guardar a resposta inteira
+package main
+
+import (
+	"errors"  // Erro de resposta maior que -max-size
+	"fmt"     // Formatação dos tamanhos e dos nomes alternativos
+	"io"      // Cópia em partes do corpo
+	"net/url" // Caminho da URL para nomear o arquivo
+	"os"      // Criação e remoção dos arquivos de saída
+	"path"    // Último segmento do caminho da URL
+	"strconv" // Conversão dos tamanhos de -max-size
+	"strings" // Sufixos de -max-size e caracteres do nome do arquivo
+)
+
+// errTooLarge indica que a resposta passou de -max-size
+var errTooLarge = errors.New("resposta maior que o limite de -max-size")
+
+// output é o destino do corpo de uma resposta
+type output struct {
+	io.Writer
+	file  *os.File // nil quando o destino não é um arquivo
+	label string   // Nome do destino nas mensagens: o caminho, "stdout" ou vazio (descartado)
+}
+
+// openOutput abre o destino do corpo de rawURL conforme -o e -O
+func openOutput(rawURL string, opts options) (*output, error) {
+	switch {
+	case opts.remoteName:
+		f, err := createUnique(remoteName(rawURL))
+		if err != nil {
+			return nil, err
+		}
+		return &output{Writer: f, file: f, label: f.Name()}, nil
+	case opts.output != "" && opts.output != "-":
+		f, err := os.Create(opts.output)
+		if err != nil {
+			return nil, err
+		}
+		return &output{Writer: f, file: f, label: f.Name()}, nil
+	case opts.json && opts.output == "":
+		// O stdout é do JSON: sem -o/-O, o corpo é lido (para contar os bytes) e descartado
+		return &output{Writer: io.Discard}, nil
+	}
+	return &output{Writer: os.Stdout, label: "stdout"}, nil
+}
+
+// finish fecha o arquivo de saída; se a transferência falhou, apaga o que foi gravado pela
+// metade, para que um arquivo truncado não seja confundido com o conteúdo completo
+func (o *output) finish(failed bool) error {
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Close()
+	if failed {
+		os.Remove(o.file.Name())
+	}
+	return err
+}
+
+// remoteName deriva o nome do arquivo do último segmento do caminho da URL, como o curl -O
+// Caminhos vazios ou terminados em "/" viram "index.html", como no wget
+func remoteName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		return "index.html"
+	}
+	// u.Path já vem decodificado ("/a%2Fb" é "/a/b") e path.Base fica só com o último
+	// segmento ("b"); barras invertidas e NUL, que um nome de arquivo não pode ter, viram "_"
+	name := strings.Map(func(r rune) rune {
+		if r == '\\' || r == 0 {
+			return '_'
+		}
+		return r
+	}, path.Base(u.Path))
+	if name == "." || name == ".." {
+		return "index.html"
+	}
+	return name
+}
+
+// createUnique cria name sem sobrescrever arquivos existentes: se name já existe, tenta
+// name.1, name.2... (como o wget), pois duas URLs podem terminar no mesmo nome
+func createUnique(name string) (*os.File, error) {
+	candidate := name
+	for i := 1; ; i++ {
+		f, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if !errors.Is(err, os.ErrExist) || i > 1000 {
+			return f, err
+		}
+		candidate = fmt.Sprintf("%s.%d", name, i)
+	}
+}
+
+// copyBody copia src para dst em partes e devolve os bytes copiados
+// Com max > 0, para ao chegar em max bytes e devolve errTooLarge se ainda houver dados:
+// o Content-Length pode faltar ou estar errado, então o limite é conferido na própria cópia
+func copyBody(dst io.Writer, src io.Reader, max int64) (int64, error) {
+	if max <= 0 {
+		return io.Copy(dst, src)
+	}
+	n, err := io.Copy(dst, io.LimitReader(src, max))
+	if err != nil {
+		return n, err
+	}
+	var extra [1]byte
+	if k, _ := io.ReadFull(src, extra[:]); k > 0 {
+		return n, errTooLarge
+	}
+	return n, nil
+}
+
+// byteSize é um tamanho em bytes que aceita os sufixos k, M e G (potências de 1024)
+// Implementa flag.Value para -max-size
+type byteSize int64
+
+// sizeUnits são os sufixos aceitos, do maior para o menor
+var sizeUnits = []struct {
+	suffix string
+	mult   int64
+}{{"G", 1 << 30}, {"M", 1 << 20}, {"k", 1 << 10}}
+
+// String implementa flag.Value e fmt.Stringer: usa o maior sufixo que divide o tamanho
+func (b *byteSize) String() string {
+	if b == nil || *b == 0 {
+		return "0"
+	}
+	for _, u := range sizeUnits {
+		if int64(*b)%u.mult == 0 {
+			return strconv.FormatInt(int64(*b)/u.mult, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+// Set implementa flag.Value: "500", "64k", "10M", "1G" (K maiúsculo também vale)
+func (b *byteSize) Set(s string) error {
+	num, mult := s, int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(s, u.suffix) || strings.HasSuffix(s, strings.ToUpper(u.suffix)) {
+			num, mult = s[:len(s)-1], u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 || n > (1<<62)/mult {
+		return fmt.Errorf("tamanho %q inválido (use bytes ou um número com k, M ou G, ex.: 10M)", s)
+	}
+	*b = byteSize(n * mult)
+	return nil
+}
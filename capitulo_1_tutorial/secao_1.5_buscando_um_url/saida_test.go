@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRemoteName confere o nome de arquivo tirado da URL por -O
+func TestRemoteName(t *testing.T) {
+	tests := []struct{ url, want string }{
+		{"http://gopl.io/doc/livro.pdf", "livro.pdf"},
+		{"http://gopl.io/livro.pdf?v=2#cap1", "livro.pdf"},
+		{"http://gopl.io", "index.html"},
+		{"http://gopl.io/", "index.html"},
+		{"http://gopl.io/doc/", "index.html"},
+		{"http://gopl.io/..", "index.html"},
+		{"http://gopl.io/a%2Fb", "b"},
+		{"http://gopl.io/a%5Cb", "a_b"},
+		{"http://gopl.io/nul%00.txt", "nul_.txt"},
+		{"http://gopl.io/caf%C3%A9.html", "café.html"},
+	}
+	for _, tt := range tests {
+		if got := remoteName(tt.url); got != tt.want {
+			t.Errorf("remoteName(%q) = %q, esperado %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+// TestCreateUnique confere que um arquivo existente nunca é sobrescrito
+func TestCreateUnique(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "index.html")
+	for _, want := range []string{name, name + ".1", name + ".2"} {
+		f, err := createUnique(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if f.Name() != want {
+			t.Errorf("createUnique criou %s, esperado %s", f.Name(), want)
+		}
+	}
+}
+
+// TestCopyBody confere o corte de -max-size na própria cópia
+func TestCopyBody(t *testing.T) {
+	tests := []struct {
+		body    string
+		max     int64
+		wantN   int64
+		wantErr error
+	}{
+		{"12345", 0, 5, nil},
+		{"12345", 5, 5, nil},
+		{"12345", 10, 5, nil},
+		{"123456", 5, 5, errTooLarge},
+	}
+	for _, tt := range tests {
+		var dst bytes.Buffer
+		n, err := copyBody(&dst, strings.NewReader(tt.body), tt.max)
+		if n != tt.wantN || !errors.Is(err, tt.wantErr) || int64(dst.Len()) != n {
+			t.Errorf("copyBody(%q, %d) = %d, %v (%d gravados); esperado %d, %v",
+				tt.body, tt.max, n, err, dst.Len(), tt.wantN, tt.wantErr)
+		}
+	}
+}
+
+// TestFetchOutputRemoved confere que o arquivo de -o não fica pela metade: é apagado quando
+// a resposta passa de -max-size ou a conexão cai no meio do corpo
+func TestFetchOutputRemoved(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/anunciado":
+			// Content-Length conhecido: recusado antes de criar o arquivo
+			io.WriteString(w, strings.Repeat("x", 2048))
+		case "/sem-tamanho":
+			// Sem Content-Length (chunked): só a cópia percebe que passou do limite
+			for i := 0; i < 4; i++ {
+				io.WriteString(w, strings.Repeat("x", 512))
+				w.(http.Flusher).Flush()
+			}
+		case "/cai":
+			// Anuncia 100 bytes, manda 10 e derruba a conexão
+			w.Header().Set("Content-Length", "100")
+			io.WriteString(w, "0123456789")
+			w.(http.Flusher).Flush()
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		default:
+			io.WriteString(w, "completo")
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		want     int
+		wantFile bool
+	}{
+		{"/anunciado", exitTooLarge, false},
+		{"/sem-tamanho", exitTooLarge, false},
+		{"/cai", exitRead, false},
+		{"/ok", 0, true},
+	}
+	for _, tt := range tests {
+		opts := testOptions()
+		opts.maxSize = 1024
+		opts.output = filepath.Join(t.TempDir(), "saida")
+		s, stderr := fetchQuiet(t, srv.URL+tt.path, opts)
+		if s.Exit != tt.want {
+			t.Errorf("%s: código %d, esperado %d (%s)", tt.path, s.Exit, tt.want, stderr)
+		}
+		if _, err := os.Stat(opts.output); (err == nil) != tt.wantFile {
+			t.Errorf("%s: arquivo existe = %v, esperado %v", tt.path, err == nil, tt.wantFile)
+		}
+	}
+}
+
+// TestByteSize confere os sufixos de -max-size
+func TestByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want byteSize
+	}{
+		{"0", 0}, {"500", 500}, {"64k", 64 << 10}, {"64K", 64 << 10}, {"10M", 10 << 20}, {"1G", 1 << 30},
+	}
+	for _, tt := range tests {
+		var b byteSize
+		if err := b.Set(tt.in); err != nil || b != tt.want {
+			t.Errorf("Set(%q) = %d, %v; esperado %d", tt.in, b, err, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "-1", "10T", "k", "1.5M", "99999999999G"} {
+		var b byteSize
+		if err := b.Set(bad); err == nil {
+			t.Errorf("Set(%q) = %d, esperado erro", bad, b)
+		}
+	}
+}
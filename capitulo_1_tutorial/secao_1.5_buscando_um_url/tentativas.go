@@ -0,0 +1,200 @@
+// Tempos limite e novas tentativas: cada tentativa é cancelada por um context quando passa
+// -timeout sem receber dados, a execução inteira respeita -max-time, e erros de rede e
+// status transitórios (429, 5xx) são repetidos com espera exponencial e aleatória
+package main
+
+import (
+	"context"   // Cancelamento das tentativas e do tempo total
+	"errors"    // Erros de tempo esgotado e comparação de causas
+	"fmt"       // Mensagens das novas tentativas
+	"io"        // Descarte do corpo das respostas repetidas
+	"math/rand" // Sorteio da espera (jitter)
+	"net/http"  // Requisições, status e Retry-After
+	"os"        // Mensagens no stderr
+	"strconv"   // Retry-After em segundos
+	"strings"   // Limpeza do Retry-After
+	"time"      // Temporizadores e esperas
+)
+
+var (
+	errIdle    = errors.New("tempo de -timeout esgotado sem receber dados")
+	errMaxTime = errors.New("tempo total de -max-time esgotado")
+)
+
+// buildError é uma falha ao montar a requisição (ex.: o arquivo de -d sumiu)
+// Não é uma falha de rede: não adianta tentar de novo
+type buildError struct{ err error }
+
+func (e buildError) Error() string { return "ao montar a requisição: " + e.err.Error() }
+
+// send envia a requisição para url, repetindo-a conforme -retry
+// Devolve a última resposta com o corpo ainda por ler, a função que encerra o contexto da
+// tentativa (chamar depois de fechar o corpo) e quantas tentativas foram feitas
+func send(ctx context.Context, client *http.Client, url string, opts options) (resp *http.Response, stop func(), attempts int, err error) {
+	for {
+		attempts++
+		resp, stop, err = attempt(ctx, client, url, opts)
+		if attempts > opts.retry || ctx.Err() != nil || !retryable(resp, err) {
+			return resp, stop, attempts, err
+		}
+
+		reason := ""
+		wait := backoff(attempts-1, opts.retryDelay, opts.retryMaxDelay)
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			// O servidor pode dizer quando voltar; esperar menos que isso é desrespeitá-lo
+			if d, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if d > opts.retryMaxDelay {
+					opts.logf("%s: %s pede Retry-After de %s, mais que -retry-max-delay %s; desistindo\n",
+						url, resp.Status, d.Round(time.Second), opts.retryMaxDelay)
+					return resp, stop, attempts, err
+				}
+				wait = d
+			}
+		}
+		// Não adianta esperar se o tempo total vai acabar antes da próxima tentativa
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			opts.logf("%s: %s; a próxima tentativa passaria de -max-time, desistindo\n", url, reason)
+			return resp, stop, attempts, err
+		}
+		if resp != nil {
+			// Lê um pouco do corpo antes de fechar, para que a conexão possa ser reaproveitada
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		stop()
+
+		opts.logf("%s: tentativa %d de %d: %s; nova tentativa em %s\n",
+			url, attempts, opts.retry+1, reason, wait.Round(time.Millisecond))
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, func() {}, attempts, context.Cause(ctx)
+		}
+	}
+}
+
+// attempt faz uma única tentativa, com um contexto próprio derivado de ctx
+// O contexto é cancelado quando passa opts.timeout sem atividade: até os headers chegarem
+// e, depois, entre uma leitura e outra do corpo. Um download grande mas contínuo não expira;
+// um servidor que parou de responder, sim
+func attempt(ctx context.Context, client *http.Client, url string, opts options) (*http.Response, func(), error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	var timer *time.Timer
+	if opts.timeout > 0 {
+		timer = time.AfterFunc(opts.timeout, func() { cancel(errIdle) })
+	}
+	stop := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		cancel(nil)
+	}
+
+	req, err := newRequest(ctx, url, opts)
+	if err != nil {
+		stop()
+		return nil, stop, buildError{err}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// "context canceled" não diz nada; a causa diz qual limite estourou
+		// (antes de stop, que cancela o contexto e esconderia o erro original)
+		if ctx.Err() != nil {
+			err = context.Cause(ctx)
+		}
+		stop()
+		return nil, stop, err
+	}
+	resp.Body = &idleBody{ReadCloser: resp.Body, ctx: ctx, timer: timer, timeout: opts.timeout}
+	return resp, stop, nil
+}
+
+// idleBody adia o cancelamento da tentativa a cada leitura que traz dados
+type idleBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	timer   *time.Timer // nil sem -timeout
+	timeout time.Duration
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && b.timer != nil {
+		b.timer.Reset(b.timeout)
+	}
+	if err != nil && err != io.EOF && b.ctx.Err() != nil {
+		err = context.Cause(b.ctx)
+	}
+	return n, err
+}
+
+// retryable informa se vale tentar de novo: erros de rede (menos as recusas da política de
+// redirecionamento e os certificados inválidos, que se repetiriam) e os status que indicam
+// uma falha passageira (408 tempo esgotado, 429 excesso de requisições, 500, 502, 503 e 504)
+func retryable(resp *http.Response, err error) bool {
+	var berr buildError
+	switch {
+	case errors.As(err, &berr), errors.Is(err, errMaxTime), errors.Is(err, errRedirectPolicy), isCertError(err):
+		return false
+	case err != nil:
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff devolve a espera depois da falha n (0 = primeira)
+// A espera dobra a cada falha, de base até max, e é sorteada entre a metade e o valor
+// cheio ("equal jitter"): vários clientes que falharam juntos não voltam todos juntos
+func backoff(n int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter interpreta o header Retry-After: segundos ("120") ou uma data HTTP
+// ("Wed, 21 Oct 2015 07:28:00 GMT"); ok é false se o header falta ou é inválido
+func retryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// logf escreve uma mensagem de progresso no stderr, a menos que -q tenha sido usada
+func (o options) logf(format string, args ...any) {
+	if !o.quiet {
+		fmt.Fprintf(os.Stderr, "buscando_um_url: "+format, args...)
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testOptions devolve opções com esperas curtas, para os testes não demorarem
+func testOptions() options {
+	return options{
+		quiet:         true,
+		timeout:       time.Second,
+		retryDelay:    time.Millisecond,
+		retryMaxDelay: 10 * time.Millisecond,
+	}
+}
+
+// statusServer responde com os status de codes, um por requisição; depois do último,
+// repete o último. hits conta as requisições recebidas
+func statusServer(t *testing.T, hits *int32, codes ...int) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(hits, 1))
+		w.WriteHeader(codes[min(n, len(codes))-1])
+		io.WriteString(w, "corpo")
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// mustClient cria o cliente de opts ou encerra o teste
+func mustClient(t *testing.T, opts options) *http.Client {
+	t.Helper()
+	client, err := newClient(opts)
+	if err != nil {
+		t.Fatalf("newClient: %v", err)
+	}
+	return client
+}
+
+// sendOnce chama send e já fecha a resposta, devolvendo só o status
+func sendOnce(t *testing.T, url string, opts options) (status, attempts int, err error) {
+	t.Helper()
+	resp, stop, attempts, err := send(context.Background(), mustClient(t, opts), url, opts)
+	defer stop()
+	if resp != nil {
+		status = resp.StatusCode
+		resp.Body.Close()
+	}
+	return status, attempts, err
+}
+
+// TestSendRetries confere quais respostas são repetidas e quantas vezes
+func TestSendRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		codes        []int
+		retry        int
+		wantStatus   int
+		wantAttempts int
+	}{
+		{"sucesso de primeira", []int{200}, 3, 200, 1},
+		{"503 passageiro", []int{503, 503, 200}, 3, 200, 3},
+		{"429 e 502", []int{429, 502, 200}, 3, 200, 3},
+		{"500 até acabarem as tentativas", []int{500}, 2, 500, 3},
+		{"sem -retry", []int{503, 200}, 0, 503, 1},
+		{"404 não é repetido", []int{404, 200}, 3, 404, 1},
+		{"501 não é passageiro", []int{501, 200}, 3, 501, 1},
+	}
+	for _, tt := range tests {
+		var hits int32
+		srv := statusServer(t, &hits, tt.codes...)
+		opts := testOptions()
+		opts.retry = tt.retry
+		status, attempts, err := sendOnce(t, srv.URL, opts)
+		if err != nil {
+			t.Errorf("%s: erro inesperado: %v", tt.name, err)
+			continue
+		}
+		if status != tt.wantStatus || attempts != tt.wantAttempts || int(hits) != tt.wantAttempts {
+			t.Errorf("%s: status %d em %d tentativas (%d no servidor), esperado %d em %d",
+				tt.name, status, attempts, hits, tt.wantStatus, tt.wantAttempts)
+		}
+	}
+}
+
+// TestSendNetworkError confere que erros de conexão também são repetidos
+func TestSendNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close() // Nada mais escuta nesse endereço: conexão recusada
+
+	opts := testOptions()
+	opts.retry = 2
+	_, attempts, err := sendOnce(t, url, opts)
+	if err == nil {
+		t.Fatal("esperado erro de conexão")
+	}
+	if attempts != 3 {
+		t.Errorf("%d tentativas, esperado 3", attempts)
+	}
+}
+
+// TestSendRetryAfter confere que a espera pedida pelo servidor é respeitada e que um
+// Retry-After maior que -retry-max-delay faz desistir em vez de voltar antes da hora
+func TestSendRetryAfter(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	opts := testOptions()
+	opts.retry = 1
+	opts.retryMaxDelay = 5 * time.Second
+	start := time.Now()
+	status, attempts, err := sendOnce(t, srv.URL, opts)
+	if err != nil || status != 200 || attempts != 2 {
+		t.Fatalf("status %d em %d tentativas, erro %v; esperado 200 em 2", status, attempts, err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("nova tentativa depois de %s, antes do Retry-After de 1s", elapsed)
+	}
+
+	// Com -retry-max-delay menor que o Retry-After, a resposta 429 é devolvida na hora
+	atomic.StoreInt32(&hits, 0)
+	opts.retryMaxDelay = 100 * time.Millisecond
+	status, attempts, err = sendOnce(t, srv.URL, opts)
+	if err != nil || status != http.StatusTooManyRequests || attempts != 1 {
+		t.Errorf("status %d em %d tentativas, erro %v; esperado 429 em 1", status, attempts, err)
+	}
+}
+
+// TestAttemptTimeout confere -timeout antes dos headers e no meio do corpo
+func TestAttemptTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/corpo" {
+			// Manda o começo do corpo e para de responder
+			io.WriteString(w, "começo")
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	opts := testOptions()
+	opts.timeout = 50 * time.Millisecond
+	start := time.Now()
+	_, attempts, err := sendOnce(t, srv.URL+"/headers", opts)
+	if !errors.Is(err, errIdle) || attempts != 1 {
+		t.Errorf("headers: erro %v em %d tentativas, esperado %v em 1", err, attempts, errIdle)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("headers: o tempo limite levou %s", elapsed)
+	}
+
+	resp, stop, _, err := send(context.Background(), mustClient(t, opts), srv.URL+"/corpo", opts)
+	if err != nil {
+		t.Fatalf("corpo: os headers deveriam chegar: %v", err)
+	}
+	defer stop()
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, errIdle) {
+		t.Errorf("corpo: erro %v, esperado %v", err, errIdle)
+	}
+	if string(body) != "começo" {
+		t.Errorf("corpo: lido %q antes do tempo limite, esperado %q", body, "começo")
+	}
+}
+
+// TestSendMaxTime confere que -max-time interrompe as novas tentativas
+func TestSendMaxTime(t *testing.T) {
+	var hits int32
+	srv := statusServer(t, &hits, 503)
+	opts := testOptions()
+	opts.retry = 100
+	opts.retryDelay = 20 * time.Millisecond
+	opts.retryMaxDelay = 20 * time.Millisecond
+
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 200*time.Millisecond, errMaxTime)
+	defer cancel()
+	start := time.Now()
+	resp, stop, attempts, err := send(ctx, mustClient(t, opts), srv.URL, opts)
+	defer stop()
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("send levou %s com -max-time de 200ms", elapsed)
+	}
+	if attempts > 100 || attempts < 2 {
+		t.Errorf("%d tentativas, esperado algumas antes de -max-time", attempts)
+	}
+	// Ou desistiu antes da espera que passaria do limite (e devolve o 503), ou o limite
+	// estourou durante uma tentativa
+	if err != nil && !errors.Is(err, errMaxTime) {
+		t.Errorf("erro %v, esperado nil ou %v", err, errMaxTime)
+	}
+}
+
+// TestBackoff confere os limites da espera sorteada
+func TestBackoff(t *testing.T) {
+	base, max := 100*time.Millisecond, time.Second
+	for n, full := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		full *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if d := backoff(n, base, max); d < full/2 || d > full {
+				t.Fatalf("backoff(%d) = %s, esperado entre %s e %s", n, d, full/2, full)
+			}
+		}
+	}
+	if d := backoff(1000, base, max); d > max {
+		t.Errorf("backoff(1000) = %s passa do máximo %s", d, max)
+	}
+}
+
+// TestRetryAfter confere as duas formas do header Retry-After
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 12, 30, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"-5", 0, false},
+		{"amanhã", 0, false},
+		{"Tue, 30 Dec 2025 12:00:30 GMT", 30 * time.Second, true},
+		{"Tue, 30 Dec 2025 11:00:00 GMT", 0, true}, // Data no passado: pode tentar já
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v; esperado %s, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
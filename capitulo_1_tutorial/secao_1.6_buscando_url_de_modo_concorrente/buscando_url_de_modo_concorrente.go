@@ -3,26 +3,66 @@ package main
 
 // Importa os pacotes necessários para o programa
 import (
-	"fmt"      // Para formatação e impressão de strings
-	"io"       // Para operações de entrada e saída (I/O)
-	"net/http" // Para fazer requisições HTTP
-	"os"       // Para acessar argumentos da linha de comando
-	"time"     // Para medir tempo de execução
+	"context"    // Para os tempos limite das requisições e da execução inteira
+	"crypto/tls" // Para reconhecer falhas de certificado, que não adianta repetir
+	"errors"     // Para o erro de tempo total esgotado
+	"flag"       // Para ler as opções de tempo e de novas tentativas
+	"fmt"        // Para formatação e impressão de strings
+	"io"         // Para operações de entrada e saída (I/O)
+	"math/rand"  // Para sortear a espera entre tentativas
+	"net/http"   // Para fazer requisições HTTP
+	"os"         // Para a mensagem e o código de saída de flags inválidas
+	"strconv"    // Para ler o header Retry-After
+	"time"       // Para medir tempo de execução
 )
 
+// errMaxTime é a causa do cancelamento quando a execução inteira passa de -max-time
+var errMaxTime = errors.New("tempo total de -max-time esgotado")
+
+// fetcher reúne o cliente HTTP e as regras de tempo e de novas tentativas
+// O livro usava http.Get, cujo cliente padrão não tem tempo limite: um servidor travado
+// prendia a goroutine (e o programa) para sempre
+type fetcher struct {
+	client   *http.Client
+	timeout  time.Duration // Tempo máximo de cada tentativa, incluindo o corpo (0 = sem limite)
+	retries  int           // Novas tentativas depois de erros de rede, 408, 429 e 5xx
+	delay    time.Duration // Espera antes da primeira nova tentativa; dobra a cada uma
+	maxDelay time.Duration // Maior espera (e maior Retry-After aceito)
+}
+
 // Função principal que será executada ao iniciar o programa
 func main() {
+	f := fetcher{client: &http.Client{}}
+	flag.DurationVar(&f.timeout, "timeout", 30*time.Second, "tempo máximo de cada tentativa, incluindo o corpo (0 = sem limite)")
+	flag.IntVar(&f.retries, "retry", 0, "novas tentativas depois de erros de rede e respostas 408, 429, 500, 502, 503 e 504")
+	flag.DurationVar(&f.delay, "retry-delay", time.Second, "espera antes da primeira nova tentativa; dobra a cada uma, com sorteio")
+	flag.DurationVar(&f.maxDelay, "retry-max-delay", 30*time.Second, "maior espera entre tentativas; um Retry-After maior faz desistir")
+	maxTime := flag.Duration("max-time", 0, "tempo máximo da execução inteira (0 = sem limite)")
+	flag.Parse()
+	if err := f.validate(*maxTime); err != nil {
+		fmt.Fprintf(os.Stderr, "buscando_url_de_modo_concorrente: %v\n", err)
+		os.Exit(2)
+	}
+
+	// O contexto raiz cancela todas as buscas quando -max-time se esgota
+	ctx := context.Background()
+	if *maxTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, *maxTime, errMaxTime)
+		defer cancel()
+	}
+
 	// Registra o momento de início da execução do programa
 	start := time.Now()
 	// Cria um canal (channel) para comunicação entre goroutines, que enviará strings
-	ch := make (chan string)
-	// Itera sobre os argumentos da linha de comando (os.Args[1:] pula o nome do programa)
-	for _, url := range os.Args[1:] {
+	ch := make(chan string)
+	// Itera sobre os argumentos da linha de comando (flag.Args() já pula o programa e as flags)
+	for _, url := range flag.Args() {
 		// Inicia uma goroutine (execução concorrente) para buscar cada URL
-		go fetch(url, ch)
+		go f.fetch(ctx, url, ch)
 	}
 	// Aguarda receber uma resposta de cada goroutine lançada
-	for range os.Args[1:] {
+	for range flag.Args() {
 		// Imprime o resultado recebido do canal
 		fmt.Println(<-ch)
 	}
@@ -32,29 +72,132 @@ func main() {
 
 // Função que busca uma URL e envia o resultado através do canal
 // ch chan<- string indica que o canal é apenas para envio (send-only)
-func fetch(url string, ch chan<- string) {
+func (f fetcher) fetch(ctx context.Context, url string, ch chan<- string) {
 	// Registra o momento de início desta requisição específica
 	start := time.Now()
-	// Faz uma requisição HTTP GET para a URL fornecida
-	resp, err := http.Get(url)
-	// Verifica se houve erro na requisição
+	var nbytes int64
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		var wait time.Duration
+		nbytes, retry, wait, err = f.try(ctx, url)
+		if err == nil || !retry || attempt > f.retries || ctx.Err() != nil {
+			break
+		}
+		// Sem Retry-After, a espera dobra a cada tentativa; um Retry-After longo demais
+		// faz desistir, em vez de voltar antes do que o servidor pediu
+		if wait == 0 {
+			wait = backoff(attempt-1, f.delay, f.maxDelay)
+		} else if wait > f.maxDelay {
+			// A linha de erro explica por que não houve nova tentativa
+			err = fmt.Errorf("%v; desistindo: Retry-After de %s passa de -retry-max-delay (%s)",
+				err, wait.Round(time.Second), f.maxDelay)
+			break
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+	}
+	// Verifica se houve erro na requisição ou ao ler o corpo da resposta
 	if err != nil {
 		// Envia mensagem de erro pelo canal e retorna
 		ch <- fmt.Sprintf("erro ao buscar %s: %v", url, err)
 		return
 	}
+	// Calcula o tempo decorrido desde o início desta requisição em segundos
+	secs := time.Since(start).Seconds()
+	// Envia pelo canal uma string formatada com: tempo, número de bytes e URL
+	ch <- fmt.Sprintf("%.2fs %7d %s", secs, nbytes, url)
+}
+
+// try faz uma tentativa de buscar url e conta os bytes do corpo
+// retry informa se vale tentar de novo; wait é o Retry-After da resposta (0 se ausente)
+func (f fetcher) try(ctx context.Context, url string) (nbytes int64, retry bool, wait time.Duration, err error) {
+	// Cada tentativa tem o seu próprio prazo, derivado do contexto da execução
+	if f.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, false, 0, err
+	}
+	// Faz a requisição HTTP GET para a URL fornecida
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, !isCertError(err), 0, cause(ctx, err)
+	}
 	// Copia o corpo da resposta para io.Discard (descarta o conteúdo) e conta os bytes
-	nbytes, err := io.Copy(io.Discard, resp.Body)
+	nbytes, err = io.Copy(io.Discard, resp.Body)
 	// Fecha o corpo da resposta HTTP para liberar recursos
 	resp.Body.Close()
-	// Verifica se houve erro ao ler o corpo da resposta
 	if err != nil {
-		// Envia mensagem de erro pelo canal e retorna
-		ch <- fmt.Sprintf("erro ao ler %s : %v", url, err)
-		return
+		return nbytes, true, 0, fmt.Errorf("erro ao ler: %v", cause(ctx, err))
 	}
-	// Calcula o tempo decorrido desde o início desta requisição em segundos
-	secs := time.Since(start).Seconds()
-	// Envia pelo canal uma string formatada com: tempo, número de bytes e URL
-	ch <- fmt.Sprintf("%.2fs %7d %s", secs, nbytes, url)
-}
\ No newline at end of file
+	// 408, 429 e os 5xx passageiros indicam que vale a pena tentar de novo mais tarde
+	// (os mesmos status que o buscando_um_url da seção 1.5 repete)
+	switch resp.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return nbytes, true, retryAfter(resp.Header.Get("Retry-After")), fmt.Errorf("o servidor respondeu %s", resp.Status)
+	}
+	return nbytes, false, 0, nil
+}
+
+// validate confere as flags de tempo e de novas tentativas, como o buscando_um_url da seção 1.5
+func (f fetcher) validate(maxTime time.Duration) error {
+	switch {
+	case f.timeout < 0 || maxTime < 0 || f.delay < 0 || f.maxDelay < 0:
+		return errors.New("tempos não podem ser negativos")
+	case f.retries < 0:
+		return errors.New("-retry não pode ser negativo")
+	}
+	return nil
+}
+
+// isCertError informa se err é uma falha na verificação do certificado do servidor
+// Não adianta tentar de novo: o certificado vai continuar o mesmo
+func isCertError(err error) bool {
+	var verr *tls.CertificateVerificationError
+	return errors.As(err, &verr)
+}
+
+// cause troca o "context deadline exceeded" genérico pela causa do cancelamento
+func cause(ctx context.Context, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+	if c := context.Cause(ctx); c != context.DeadlineExceeded {
+		return c
+	}
+	return errors.New("tempo de -timeout esgotado")
+}
+
+// backoff devolve a espera depois da falha n (0 = primeira): dobra a cada falha, de base
+// até max, sorteada entre a metade e o valor cheio para as goroutines não voltarem juntas
+func backoff(n int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 0; i < n && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter lê o header Retry-After em segundos ou como data HTTP (0 se ausente ou inválido)
+func retryAfter(v string) time.Duration {
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && time.Until(t) > 0 {
+		return time.Until(t)
+	}
+	return 0
+}
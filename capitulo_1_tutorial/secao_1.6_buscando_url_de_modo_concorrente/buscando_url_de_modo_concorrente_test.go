@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testFetcher devolve um fetcher com esperas curtas, para os testes não demorarem
+func testFetcher(retries int) fetcher {
+	return fetcher{
+		client:   &http.Client{},
+		timeout:  time.Second,
+		retries:  retries,
+		delay:    time.Millisecond,
+		maxDelay: 10 * time.Millisecond,
+	}
+}
+
+// fetchLine chama fetch e devolve a linha enviada pelo canal
+func fetchLine(ctx context.Context, f fetcher, url string) string {
+	ch := make(chan string, 1)
+	f.fetch(ctx, url, ch)
+	return <-ch
+}
+
+// TestFetchRetries confere que respostas 503 são repetidas até o sucesso e que, sem
+// novas tentativas, viram uma linha de erro
+func TestFetchRetries(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "12345")
+	}))
+	defer srv.Close()
+
+	line := fetchLine(context.Background(), testFetcher(3), srv.URL)
+	if !strings.HasSuffix(line, "      5 "+srv.URL) || hits != 3 {
+		t.Errorf("linha %q depois de %d requisições, esperado sucesso com 5 bytes na 3ª", line, hits)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	line = fetchLine(context.Background(), testFetcher(0), srv.URL)
+	if !strings.HasPrefix(line, "erro ao buscar") || !strings.Contains(line, "503") || hits != 1 {
+		t.Errorf("linha %q depois de %d requisições, esperado erro 503 na 1ª", line, hits)
+	}
+}
+
+// TestFetchRetryStatuses confere que os status repetidos são os mesmos do buscando_um_url
+// (seção 1.5): 408, 429, 500, 502, 503 e 504; os demais erros não geram nova tentativa
+func TestFetchRetryStatuses(t *testing.T) {
+	for status, want := range map[int]int32{
+		http.StatusRequestTimeout: 2, http.StatusTooManyRequests: 2, http.StatusInternalServerError: 2,
+		http.StatusBadGateway: 2, http.StatusServiceUnavailable: 2, http.StatusGatewayTimeout: 2,
+		http.StatusNotFound: 1, http.StatusNotImplemented: 1,
+	} {
+		var hits int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(status)
+		}))
+		fetchLine(context.Background(), testFetcher(1), srv.URL)
+		srv.Close()
+		if hits != want {
+			t.Errorf("status %d: %d requisições, esperadas %d", status, hits, want)
+		}
+	}
+}
+
+// TestFetchRetryAfter confere que um Retry-After maior que -retry-max-delay faz desistir
+func TestFetchRetryAfter(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	line := fetchLine(context.Background(), testFetcher(3), srv.URL)
+	if !strings.Contains(line, "429") || !strings.Contains(line, "-retry-max-delay") || hits != 1 {
+		t.Errorf("linha %q depois de %d requisições, esperado desistir no 429 citando -retry-max-delay", line, hits)
+	}
+}
+
+// TestFetchCertError confere que um certificado inválido não gera novas tentativas
+func TestFetchCertError(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	line := fetchLine(context.Background(), testFetcher(3), srv.URL)
+	if !strings.HasPrefix(line, "erro ao buscar") || atomic.LoadInt32(&conns) != 1 {
+		t.Errorf("linha %q depois de %d conexões, esperado erro de certificado na 1ª", line, conns)
+	}
+}
+
+// TestValidate confere que tempos e -retry negativos são rejeitados
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(f *fetcher)
+		maxTime time.Duration
+		ok      bool
+	}{
+		{"padrão", func(f *fetcher) {}, 0, true},
+		{"-retry negativo", func(f *fetcher) { f.retries = -1 }, 0, false},
+		{"-timeout negativo", func(f *fetcher) { f.timeout = -1 }, 0, false},
+		{"-retry-delay negativo", func(f *fetcher) { f.delay = -1 }, 0, false},
+		{"-retry-max-delay negativo", func(f *fetcher) { f.maxDelay = -1 }, 0, false},
+		{"-max-time negativo", func(f *fetcher) {}, -1, false},
+	}
+	for _, tt := range tests {
+		f := testFetcher(0)
+		tt.change(&f)
+		if err := f.validate(tt.maxTime); (err == nil) != tt.ok {
+			t.Errorf("%s: erro %v, esperado ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+// TestFetchTimeout confere que um servidor travado não prende a goroutine: -timeout
+// encerra cada tentativa e -max-time encerra tudo
+func TestFetchTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	f := testFetcher(1)
+	f.timeout = 50 * time.Millisecond
+	start := time.Now()
+	line := fetchLine(context.Background(), f, srv.URL)
+	if !strings.Contains(line, "-timeout") || time.Since(start) > 2*time.Second {
+		t.Errorf("linha %q depois de %s, esperado tempo limite de -timeout", line, time.Since(start))
+	}
+
+	f.timeout = 0
+	ctx, cancel := context.WithTimeoutCause(context.Background(), 50*time.Millisecond, errMaxTime)
+	defer cancel()
+	line = fetchLine(ctx, f, srv.URL)
+	if !strings.Contains(line, errMaxTime.Error()) {
+		t.Errorf("linha %q, esperado %q", line, errMaxTime)
+	}
+}
@@ -0,0 +1,3 @@
+module buscando_url_de_modo_concorrente
+
+go 1.21